@@ -0,0 +1,210 @@
+package timeseries
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// AlignOpts configures TsContainer.Align.
+//
+// Fields:
+//   - Mode:   how the common timeline is built (union, intersection, grid).
+//   - Period: grid step for AlignGrid; must be > 0 in that mode.
+//   - Anchor: grid phase for AlignGrid. The zero value means the Unix epoch.
+//   - Method: how a series is evaluated at timestamps it lacks. Use
+//     InterpExact to leave those points missing.
+//   - Extrap: what happens outside the span of each series.
+type AlignOpts struct {
+	Mode   AlignMode
+	Period time.Duration
+	Anchor time.Time
+	Method Interp
+	Extrap Extrapolation
+}
+
+// Align returns a new container in which every series is evaluated on one
+// common timeline, so that the i-th point of each series shares the same
+// Chron. Values at timestamps a series does not have are obtained with
+// Resample(timeline, opts.Method, opts.Extrap); the receiver is not
+// modified.
+//
+// Errors:
+//   - ErrBounds on an unknown mode.
+//   - ErrZeroPeriod if Mode is AlignGrid and Period <= 0.
+//   - ErrUnsorted if a series is not strictly increasing.
+//   - ErrAnchorOutOfRange as for RegularizeWith.
+func (tsc *TsContainer) Align(opts AlignOpts) (TsContainer, error) {
+	out := NewTsContainer()
+	out.Name, out.Comment = tsc.Name, tsc.Comment
+
+	var timeline []time.Time
+	switch opts.Mode {
+	case AlignUnion, AlignIntersection:
+		timeline = tsc.commonTimestamps(opts.Mode == AlignIntersection)
+	case AlignGrid:
+		var err error
+		if timeline, err = tsc.gridTimestamps(opts.Period, opts.Anchor); err != nil {
+			return out, err
+		}
+	default:
+		return out, ErrBounds
+	}
+
+	for name, ts := range tsc.Ts {
+		if ts == nil {
+			continue
+		}
+		aligned, err := ts.Resample(timeline, opts.Method, opts.Extrap)
+		if err != nil {
+			return out, err
+		}
+		aligned.Comment = ts.Comment
+		out.Ts[name] = &aligned
+	}
+	return out, nil
+}
+
+// commonTimestamps returns the sorted union of the timestamps of all series,
+// or their intersection when intersect is set.
+func (tsc *TsContainer) commonTimestamps(intersect bool) []time.Time {
+	// Instants are keyed by Unix nanoseconds so that equal instants in
+	// different locations are merged.
+	count := make(map[int64]int)
+	first := make(map[int64]time.Time)
+	series := 0
+	for _, ts := range tsc.Ts {
+		if ts == nil {
+			continue
+		}
+		series++
+		seen := make(map[int64]bool, len(ts.DataSeries))
+		for _, d := range ts.DataSeries {
+			key := d.Chron.UnixNano()
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			if count[key] == 0 {
+				first[key] = d.Chron
+			}
+			count[key]++
+		}
+	}
+	var timeline []time.Time
+	for key, c := range count {
+		if !intersect || c == series {
+			timeline = append(timeline, first[key])
+		}
+	}
+	sort.Slice(timeline, func(i, j int) bool { return timeline[i].Before(timeline[j]) })
+	return timeline
+}
+
+// gridTimestamps returns the regular grid anchor + k*period spanning from
+// the bucket holding the earliest observation to the latest observation.
+func (tsc *TsContainer) gridTimestamps(period time.Duration, anchor time.Time) ([]time.Time, error) {
+	if period <= 0 {
+		return nil, ErrZeroPeriod
+	}
+	if anchor.IsZero() {
+		anchor = time.Unix(0, 0).UTC()
+	}
+	var first, last time.Time
+	for _, ts := range tsc.Ts {
+		if ts == nil || len(ts.DataSeries) == 0 {
+			continue
+		}
+		a, b := ts.DataSeries[0].Chron, ts.DataSeries[len(ts.DataSeries)-1].Chron
+		if first.IsZero() || a.Before(first) {
+			first = a
+		}
+		if last.IsZero() || b.After(last) {
+			last = b
+		}
+	}
+	if first.IsZero() {
+		return nil, nil
+	}
+	g := fixedGrid{period: period, anchor: anchor}
+	start, err := g.floor(first)
+	if err != nil {
+		return nil, err
+	}
+	var grid []time.Time
+	for t := start; !t.After(last); t = g.next(t) {
+		grid = append(grid, t)
+	}
+	return grid, nil
+}
+
+// AsOf performs an as-of join: for every point of left it attaches the
+// observation of right selected by direction (by default the latest one at
+// or before the left timestamp). The result carries left's timestamps and
+// right's values and status codes.
+//
+// Only right-hand observations with a usable value are candidates (finite
+// Meas, Status neither StMissing nor StInvalid). A candidate farther than
+// tolerance from the left timestamp is rejected: a tolerance of 0 only
+// accepts exact matches and NoTolerance (or any negative tolerance) means
+// no limit, as for JoinMode. Left points without a match are StMissing.
+//
+// Errors:
+//   - ErrEmptyInput if left or right is nil.
+//   - ErrBounds on an unknown direction.
+//   - ErrUnsorted if right is not strictly increasing.
+func AsOf(left, right *TimeSeries, tolerance time.Duration, direction AsOfDirection) (TimeSeries, error) {
+	if left == nil || right == nil {
+		return TimeSeries{}, ErrEmptyInput
+	}
+	out := TimeSeries{Name: right.Name}
+	if direction < AsOfBackward || direction > AsOfNearest {
+		return out, ErrBounds
+	}
+	if !right.strictlyIncreasing() {
+		return out, ErrUnsorted
+	}
+	var cands []DataUnit
+	for _, d := range right.DataSeries {
+		if isKnot(d) {
+			cands = append(cands, d)
+		}
+	}
+	n := len(cands)
+	within := func(d time.Duration) bool { return tolerance < 0 || d <= tolerance }
+
+	for _, l := range left.DataSeries {
+		t := l.Chron
+		// k is the first candidate strictly after t.
+		k := sort.Search(n, func(i int) bool { return cands[i].Chron.After(t) })
+		back, fwd := -1, -1
+		if k > 0 && within(t.Sub(cands[k-1].Chron)) {
+			back = k - 1
+		}
+		switch {
+		case k > 0 && cands[k-1].Chron.Equal(t):
+			fwd = k - 1
+		case k < n && within(cands[k].Chron.Sub(t)):
+			fwd = k
+		}
+
+		pick := -1
+		switch direction {
+		case AsOfBackward:
+			pick = back
+		case AsOfForward:
+			pick = fwd
+		case AsOfNearest:
+			pick = back
+			if fwd >= 0 && (back < 0 || cands[fwd].Chron.Sub(t) < t.Sub(cands[back].Chron)) {
+				pick = fwd
+			}
+		}
+		du := DataUnit{Chron: t, Meas: math.NaN(), Status: StMissing}
+		if pick >= 0 {
+			du.Meas, du.Status = cands[pick].Meas, cands[pick].Status
+		}
+		out.AddDataUnit(du)
+	}
+	return out, nil
+}
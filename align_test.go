@@ -0,0 +1,130 @@
+package timeseries
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func alignFixture() TsContainer {
+	t0 := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	a := TimeSeries{Name: "a"}
+	a.AddData(t0, 0)
+	a.AddData(t0.Add(10*time.Second), 10)
+	a.AddData(t0.Add(20*time.Second), 20)
+	b := TimeSeries{Name: "b"}
+	b.AddData(t0.Add(5*time.Second), 5)
+	b.AddData(t0.Add(10*time.Second), 1)
+	tsc := NewTsContainer()
+	tsc.Name = "pair"
+	tsc.Ts["a"], tsc.Ts["b"] = &a, &b
+	return tsc
+}
+
+func TestAlign_UnionAndIntersection(t *testing.T) {
+	tsc := alignFixture()
+
+	union, err := tsc.Align(AlignOpts{Mode: AlignUnion, Method: InterpLinear})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if union.Name != "pair" {
+		t.Fatalf("container metadata not preserved")
+	}
+	a, b := union.Ts["a"], union.Ts["b"]
+	if len(a.DataSeries) != 4 || len(b.DataSeries) != 4 {
+		t.Fatalf("union lengths: a=%d b=%d, want 4", len(a.DataSeries), len(b.DataSeries))
+	}
+	for i := range a.DataSeries {
+		if !a.DataSeries[i].Chron.Equal(b.DataSeries[i].Chron) {
+			t.Fatalf("point %d: timelines differ", i)
+		}
+	}
+	if !almostEq(a.DataSeries[1].Meas, 5, 1e-12) {
+		t.Fatalf("a at 5s: got %v, want 5", a.DataSeries[1].Meas)
+	}
+	if b.DataSeries[0].Status != StMissing || b.DataSeries[3].Status != StMissing {
+		t.Fatalf("b outside its span must be missing with ExtrapNone")
+	}
+
+	inter, err := tsc.Align(AlignOpts{Mode: AlignIntersection})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := inter.Ts["a"].DataSeries; len(got) != 1 || got[0].Meas != 10 {
+		t.Fatalf("intersection: got %+v, want the single point at 10s", got)
+	}
+}
+
+func TestAlign_Grid(t *testing.T) {
+	tsc := alignFixture()
+	grid, err := tsc.Align(AlignOpts{Mode: AlignGrid, Period: 4 * time.Second, Method: InterpStep, Extrap: ExtrapHold})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// 0, 4, 8, 12, 16, 20 seconds.
+	if n := len(grid.Ts["b"].DataSeries); n != 6 {
+		t.Fatalf("grid length %d, want 6", n)
+	}
+	if got := grid.Ts["b"].DataSeries[2].Meas; got != 5 {
+		t.Fatalf("b at 8s (step): got %v, want 5", got)
+	}
+	if _, err := tsc.Align(AlignOpts{Mode: AlignGrid}); err != ErrZeroPeriod {
+		t.Fatalf("zero period: got %v, want ErrZeroPeriod", err)
+	}
+	if _, err := tsc.Align(AlignOpts{Mode: AlignMode(9)}); err != ErrBounds {
+		t.Fatalf("unknown mode: got %v, want ErrBounds", err)
+	}
+}
+
+func TestAsOf(t *testing.T) {
+	t0 := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	left := TimeSeries{Name: "trades"}
+	for _, s := range []int{1, 5, 10, 30} {
+		left.AddData(t0.Add(time.Duration(s)*time.Second), 0)
+	}
+	right := TimeSeries{Name: "quotes"}
+	right.AddData(t0, 100)
+	right.AddData(t0.Add(4*time.Second), 104)
+	right.AddDataUnit(NewDataUnitWithStatus(t0.Add(6*time.Second), math.NaN(), StMissing))
+	right.AddData(t0.Add(10*time.Second), 110)
+
+	cases := []struct {
+		dir  AsOfDirection
+		tol  time.Duration
+		want []float64
+	}{
+		{AsOfBackward, NoTolerance, []float64{100, 104, 110, 110}},
+		{AsOfBackward, 5 * time.Second, []float64{100, 104, 110, math.NaN()}},
+		{AsOfBackward, 0, []float64{math.NaN(), math.NaN(), 110, math.NaN()}},
+		{AsOfForward, NoTolerance, []float64{104, 110, 110, math.NaN()}},
+		{AsOfNearest, NoTolerance, []float64{100, 104, 110, 110}},
+	}
+	for _, c := range cases {
+		got, err := AsOf(&left, &right, c.tol, c.dir)
+		if err != nil {
+			t.Fatalf("dir %d: unexpected error: %v", c.dir, err)
+		}
+		if got.Name != "quotes" {
+			t.Fatalf("result should carry the right-hand name")
+		}
+		for i, want := range c.want {
+			d := got.DataSeries[i]
+			if !d.Chron.Equal(left.DataSeries[i].Chron) || !almostEq(d.Meas, want, 0) {
+				t.Fatalf("dir %d tol %v point %d: got %v, want %v", c.dir, c.tol, i, d.Meas, want)
+			}
+			if math.IsNaN(want) && d.Status != StMissing {
+				t.Fatalf("unmatched point must be StMissing")
+			}
+		}
+	}
+	if _, err := AsOf(&left, &right, NoTolerance, AsOfDirection(7)); err != ErrBounds {
+		t.Fatalf("unknown direction: got %v, want ErrBounds", err)
+	}
+	if _, err := AsOf(nil, &right, NoTolerance, AsOfBackward); err != ErrEmptyInput {
+		t.Fatalf("nil left: got %v, want ErrEmptyInput", err)
+	}
+	if _, err := AsOf(&left, nil, NoTolerance, AsOfBackward); err != ErrEmptyInput {
+		t.Fatalf("nil right: got %v, want ErrEmptyInput", err)
+	}
+}
@@ -0,0 +1,89 @@
+package timeseries
+
+import (
+	"strconv"
+	"time"
+)
+
+// Annotation is a provenance record explaining the status of a point:
+// what set it, why, with which parameters, by whom and when. It lets a
+// rejected reading be justified after the fact.
+//
+// Fields:
+//   - Source:   the detector or rule that acted (e.g. "hampel", "quality"),
+//     or any label for a manual decision.
+//   - Reason:   a reason code (e.g. "outlier", "flatline").
+//   - Status:   the status the point was given.
+//   - Params:   the parameters in force (e.g. "threshold": "3.5").
+//   - Operator: who ran or approved the operation, if known.
+//   - At:       when the annotation was made.
+type Annotation struct {
+	Source   string            `json:"source"`
+	Reason   string            `json:"reason,omitempty"`
+	Status   StatusCode        `json:"status"`
+	Params   map[string]string `json:"params,omitempty"`
+	Operator string            `json:"operator,omitempty"`
+	At       time.Time         `json:"at"`
+}
+
+// Annotate attaches a to the point(s) at time t. Annotations accumulate:
+// a point flagged twice keeps both records.
+func (ts *TimeSeries) Annotate(t time.Time, a Annotation) {
+	if ts.Annotations == nil {
+		ts.Annotations = make(map[int64][]Annotation)
+	}
+	key := t.UnixNano()
+	ts.Annotations[key] = append(ts.Annotations[key], a)
+}
+
+// AnnotationsAt returns the annotations of the point(s) at time t, oldest
+// first, or nil.
+func (ts *TimeSeries) AnnotationsAt(t time.Time) []Annotation {
+	return ts.Annotations[t.UnixNano()]
+}
+
+// copyAnnotations returns a deep copy of the annotations, or nil.
+func (ts *TimeSeries) copyAnnotations() map[int64][]Annotation {
+	return mergeAnnotations(nil, ts.Annotations)
+}
+
+// mergeAnnotations returns a new map holding the annotations of all maps,
+// concatenated per timestamp in argument order, or nil if there are none.
+func mergeAnnotations(maps ...map[int64][]Annotation) map[int64][]Annotation {
+	var out map[int64][]Annotation
+	for _, m := range maps {
+		for k, list := range m {
+			if out == nil {
+				out = make(map[int64][]Annotation)
+			}
+			for _, a := range list {
+				if a.Params != nil {
+					params := make(map[string]string, len(a.Params))
+					for pk, pv := range a.Params {
+						params[pk] = pv
+					}
+					a.Params = params
+				}
+				out[k] = append(out[k], a)
+			}
+		}
+	}
+	return out
+}
+
+// annotationsToJSON converts annotation keys to RFC 3339 timestamps.
+func annotationsToJSON(m map[int64][]Annotation) map[string][]Annotation {
+	if len(m) == 0 {
+		return nil
+	}
+	out := make(map[string][]Annotation, len(m))
+	for k, list := range m {
+		out[time.Unix(0, k).UTC().Format(time.RFC3339Nano)] = list
+	}
+	return out
+}
+
+// formatParam renders a numeric parameter of an annotation.
+func formatParam(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
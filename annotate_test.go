@@ -0,0 +1,80 @@
+package timeseries
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestAnnotate_SurvivesCopySortMerge(t *testing.T) {
+	ts := mkTS(3, 1, 2)
+	at := time.Date(2025, 2, 1, 9, 0, 0, 0, time.UTC)
+	ts.Annotate(ts.DataSeries[0].Chron, Annotation{Source: "manual", Reason: "calibration", Operator: "jd", At: at,
+		Params: map[string]string{"ticket": "42"}})
+
+	cp := ts.Copy()
+	cp.Annotations[ts.DataSeries[0].Chron.UnixNano()][0].Params["ticket"] = "changed"
+	if got := ts.AnnotationsAt(ts.DataSeries[0].Chron); len(got) != 1 || got[0].Params["ticket"] != "42" {
+		t.Fatalf("Copy must be deep, original now %+v", got)
+	}
+
+	sorted := SortedMeasAsc(ts)
+	// The annotated point (3) is now last but keeps its annotation.
+	if got := sorted.AnnotationsAt(sorted.DataSeries[2].Chron); len(got) != 1 || got[0].Operator != "jd" {
+		t.Fatalf("sorting lost the annotation: %+v", got)
+	}
+
+	other := mkTS(9)
+	other.Annotate(other.DataSeries[0].Chron, Annotation{Source: "manual", Reason: "second look", At: at})
+	merged := Merge(&ts, &other)
+	if got := merged.AnnotationsAt(ts.DataSeries[0].Chron); len(got) != 2 || got[1].Reason != "second look" {
+		t.Fatalf("merge must concatenate annotations, got %+v", got)
+	}
+}
+
+func TestFlag_Annotates(t *testing.T) {
+	ts := mkTS(10, 11, 10, 12, 11, 13, 10, 11, 250, 12, 11, 10)
+	before := time.Now()
+	ts.Flag(MADDetector{Threshold: 3.5})
+	got := ts.AnnotationsAt(ts.DataSeries[8].Chron)
+	if len(got) != 1 || got[0].Source != "mad" || got[0].Params["threshold"] != "3.5" ||
+		got[0].Status != StOutlier || got[0].At.Before(before) {
+		t.Fatalf("unexpected annotations %+v", got)
+	}
+	if len(ts.Annotations) != 1 {
+		t.Fatalf("only the flagged point must be annotated, got %d", len(ts.Annotations))
+	}
+}
+
+func TestQualityRules_Annotate(t *testing.T) {
+	ts := mkTS(20, 200, 21)
+	q := QualityRules{Range: &RangeRule{Min: 0, Max: 100}}
+	if _, err := q.Apply(&ts); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got := ts.AnnotationsAt(ts.DataSeries[1].Chron)
+	if len(got) != 1 || got[0].Reason != "range" || got[0].Params["max"] != "100" || got[0].Status != StInvalid {
+		t.Fatalf("unexpected annotations %+v", got)
+	}
+}
+
+func TestToJSON_Annotations(t *testing.T) {
+	ts := mkTS(1, 2)
+	ts.Annotate(ts.DataSeries[1].Chron, Annotation{Source: "manual", Reason: "check", At: ts.DataSeries[1].Chron})
+	b, err := json.Marshal(ts.ToJSON())
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	var back TimeSeriesJSON
+	if err := json.Unmarshal(b, &back); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	got := back.Annotations["2025-01-01T12:01:00Z"]
+	if len(got) != 1 || got[0].Reason != "check" {
+		t.Fatalf("annotations in JSON: %s", b)
+	}
+	plain := mkTS(1)
+	if plain.ToJSON().Annotations != nil {
+		t.Fatal("series without annotations must not export any")
+	}
+}
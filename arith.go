@@ -0,0 +1,123 @@
+package timeseries
+
+import (
+	"math"
+)
+
+// Combine applies fn elementwise to two series after matching their
+// timestamps according to join, e.g. net load = consumption - production:
+//
+//	net, err := timeseries.Combine(&load, &pv, func(x, y float64) float64 { return x - y },
+//		timeseries.JoinMode{Kind: timeseries.JoinAsOf, Tolerance: 5 * time.Minute})
+//
+// The result is named after a. Each point gets the worst StatusCode of its
+// two operands (StOK < StImputed < StOutlier < StMissing < StInvalid); an
+// operand missing on the right side of an as-of join is StMissing. A NaN
+// result is never StOK: it is flagged StMissing.
+//
+// Errors:
+//   - ErrBounds if a or b is nil, or on an unknown join kind.
+//   - ErrUnsorted if an operand is not strictly increasing.
+//   - any error of RegularizeWith for JoinRegular.
+func Combine(a, b *TimeSeries, fn func(x, y float64) float64, join JoinMode) (TimeSeries, error) {
+	if a == nil || b == nil {
+		return TimeSeries{}, ErrBounds
+	}
+	out := TimeSeries{Name: a.Name}
+	switch join.Kind {
+	case JoinExact:
+		if !a.strictlyIncreasing() || !b.strictlyIncreasing() {
+			return out, ErrUnsorted
+		}
+		return combineExact(a, b, fn), nil
+	case JoinAsOf:
+		if !a.strictlyIncreasing() {
+			return out, ErrUnsorted
+		}
+		right, err := AsOf(a, b, join.Tolerance, AsOfBackward)
+		if err != nil {
+			return out, err
+		}
+		// AsOf returns one point per left point, in the same order.
+		for i, x := range a.DataSeries {
+			out.AddDataUnit(combineUnits(x, right.DataSeries[i], fn))
+		}
+		return out, nil
+	case JoinRegular:
+		ra, err := a.RegularizeWith(join.Regular)
+		if err != nil {
+			return out, err
+		}
+		rb, err := b.RegularizeWith(join.Regular)
+		if err != nil {
+			return out, err
+		}
+		ra.Name = a.Name
+		return combineExact(&ra, &rb, fn), nil
+	}
+	return out, ErrBounds
+}
+
+// combineExact merges two sorted series on their common timestamps.
+func combineExact(a, b *TimeSeries, fn func(x, y float64) float64) TimeSeries {
+	out := TimeSeries{Name: a.Name}
+	i, j := 0, 0
+	for i < len(a.DataSeries) && j < len(b.DataSeries) {
+		x, y := a.DataSeries[i], b.DataSeries[j]
+		switch {
+		case x.Chron.Before(y.Chron):
+			i++
+		case y.Chron.Before(x.Chron):
+			j++
+		default:
+			out.AddDataUnit(combineUnits(x, y, fn))
+			i++
+			j++
+		}
+	}
+	return out
+}
+
+// combineUnits applies fn to two matched observations, stamped with x.Chron.
+func combineUnits(x, y DataUnit, fn func(x, y float64) float64) DataUnit {
+	du := DataUnit{
+		Chron:  x.Chron,
+		Meas:   fn(x.Meas, y.Meas),
+		Status: worstStatus(x.Status, y.Status),
+	}
+	if math.IsNaN(du.Meas) {
+		du.Status = worstStatus(du.Status, StMissing)
+	}
+	return du
+}
+
+// Add returns ts + other, matched according to join (see Combine).
+func (ts *TimeSeries) Add(other *TimeSeries, join JoinMode) (TimeSeries, error) {
+	return Combine(ts, other, func(x, y float64) float64 { return x + y }, join)
+}
+
+// Sub returns ts - other, matched according to join (see Combine).
+func (ts *TimeSeries) Sub(other *TimeSeries, join JoinMode) (TimeSeries, error) {
+	return Combine(ts, other, func(x, y float64) float64 { return x - y }, join)
+}
+
+// Mul returns ts * other, matched according to join (see Combine).
+func (ts *TimeSeries) Mul(other *TimeSeries, join JoinMode) (TimeSeries, error) {
+	return Combine(ts, other, func(x, y float64) float64 { return x * y }, join)
+}
+
+// Div returns ts / other, matched according to join (see Combine). A
+// division by zero yields ±Inf (or NaN for 0/0, flagged StMissing).
+func (ts *TimeSeries) Div(other *TimeSeries, join JoinMode) (TimeSeries, error) {
+	return Combine(ts, other, func(x, y float64) float64 { return x / y }, join)
+}
+
+// Min returns the pointwise minimum of ts and other (see Combine).
+func (ts *TimeSeries) Min(other *TimeSeries, join JoinMode) (TimeSeries, error) {
+	return Combine(ts, other, math.Min, join)
+}
+
+// Max returns the pointwise maximum of ts and other (see Combine).
+func (ts *TimeSeries) Max(other *TimeSeries, join JoinMode) (TimeSeries, error) {
+	return Combine(ts, other, math.Max, join)
+}
@@ -0,0 +1,112 @@
+package timeseries
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func meters() (TimeSeries, TimeSeries) {
+	t0 := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	load := TimeSeries{Name: "load"}
+	pv := TimeSeries{Name: "pv"}
+	for i, v := range []float64{10, 12, 14, 16} {
+		load.AddData(t0.Add(time.Duration(i)*time.Minute), v)
+	}
+	// Production is logged 10 s late and misses the last minute.
+	for i, v := range []float64{4, 5, 6} {
+		pv.AddData(t0.Add(time.Duration(i)*time.Minute+10*time.Second), v)
+	}
+	return load, pv
+}
+
+func TestCombine_Exact(t *testing.T) {
+	load, pv := meters()
+	pv.DataSeries[1].Chron = load.DataSeries[1].Chron // the only shared timestamp
+	pv.DataSeries[1].Status = StOutlier
+	net, err := load.Sub(&pv, JoinMode{Kind: JoinExact})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(net.DataSeries) != 1 || net.DataSeries[0].Meas != 7 {
+		t.Fatalf("exact join: got %+v, want one point of 7", net.DataSeries)
+	}
+	if net.Name != "load" || net.DataSeries[0].Status != StOutlier {
+		t.Fatalf("name %q status %v, want load/StOutlier", net.Name, net.DataSeries[0].Status)
+	}
+}
+
+func TestCombine_AsOf(t *testing.T) {
+	load, pv := meters()
+	net, err := load.Sub(&pv, JoinMode{Kind: JoinAsOf, Tolerance: 2 * time.Minute})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// 12:00 has no earlier production; 12:01 uses 12:00:10, etc.
+	want := []float64{math.NaN(), 8, 9, 10}
+	for i, w := range want {
+		if !almostEq(net.DataSeries[i].Meas, w, 0) {
+			t.Fatalf("point %d: got %v, want %v", i, net.DataSeries[i].Meas, w)
+		}
+	}
+	if net.DataSeries[0].Status != StMissing {
+		t.Fatalf("unmatched point must be StMissing, got %v", net.DataSeries[0].Status)
+	}
+	// A zero tolerance only matches identical timestamps, none here.
+	exact, err := load.Sub(&pv, JoinMode{Kind: JoinAsOf})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for i, d := range exact.DataSeries {
+		if d.Status != StMissing {
+			t.Fatalf("point %d: zero tolerance must not match %v", i, d)
+		}
+	}
+}
+
+func TestCombine_RegularAndOperators(t *testing.T) {
+	load, pv := meters()
+	join := JoinMode{Kind: JoinRegular, Regular: RegularizeOpts{Period: time.Minute, Agg: AggMean}}
+	cases := []struct {
+		name string
+		op   func(*TimeSeries, JoinMode) (TimeSeries, error)
+		want []float64
+	}{
+		{"add", load.Add, []float64{14, 17, 20}},
+		{"mul", load.Mul, []float64{40, 60, 84}},
+		{"div", load.Div, []float64{2.5, 2.4, 14.0 / 6}},
+		{"min", load.Min, []float64{4, 5, 6}},
+		{"max", load.Max, []float64{10, 12, 14}},
+	}
+	for _, c := range cases {
+		got, err := c.op(&pv, join)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", c.name, err)
+		}
+		if len(got.DataSeries) != len(c.want) {
+			t.Fatalf("%s: got %d points, want %d", c.name, len(got.DataSeries), len(c.want))
+		}
+		for i, w := range c.want {
+			if !almostEq(got.DataSeries[i].Meas, w, 1e-12) {
+				t.Fatalf("%s point %d: got %v, want %v", c.name, i, got.DataSeries[i].Meas, w)
+			}
+		}
+	}
+}
+
+func TestCombine_Errors(t *testing.T) {
+	load, pv := meters()
+	if _, err := Combine(&load, &pv, math.Max, JoinMode{Kind: JoinKind(7)}); err != ErrBounds {
+		t.Fatalf("unknown join: got %v, want ErrBounds", err)
+	}
+	if _, err := Combine(nil, &pv, math.Max, JoinMode{}); err != ErrBounds {
+		t.Fatalf("nil a: got %v, want ErrBounds", err)
+	}
+	if _, err := load.Add(nil, JoinMode{Kind: JoinAsOf}); err != ErrBounds {
+		t.Fatalf("nil b: got %v, want ErrBounds", err)
+	}
+	load.DataSeries[0], load.DataSeries[1] = load.DataSeries[1], load.DataSeries[0]
+	if _, err := load.Add(&pv, JoinMode{}); err != ErrUnsorted {
+		t.Fatalf("unsorted: got %v, want ErrUnsorted", err)
+	}
+}
@@ -0,0 +1,142 @@
+package timeseries
+
+import (
+	"math"
+	"time"
+)
+
+// CounterOpts describes how a cumulative counter behaves when it decreases.
+//
+// Fields:
+//   - WrapAt: value at which the counter rolls over to zero (e.g. 65536 for
+//     a 16-bit register, 1e6 for a six digit kWh index). A decrease is then
+//     read as a wrap-around: the increment is cur + WrapAt - prev. With the
+//     zero value the counter never wraps and any decrease is a reset to
+//     zero: the increment is cur.
+type CounterOpts struct {
+	WrapAt float64
+}
+
+// DetectResets returns the indices of the points at which a monotonic
+// counter decreased, i.e. was reset or wrapped around. Only valid points
+// (Status=StOK, finite Meas) are compared; each one against the previous
+// valid point. The series is expected in chronological order.
+func (ts *TimeSeries) DetectResets() []int {
+	var idx []int
+	prev := -1
+	for i, d := range ts.DataSeries {
+		if !usable(d, false) {
+			continue
+		}
+		if prev >= 0 && d.Meas < ts.DataSeries[prev].Meas {
+			idx = append(idx, i)
+		}
+		prev = i
+	}
+	return idx
+}
+
+// Increase returns, at the timestamp t of every valid point, how much the
+// counter grew over the trailing window (t-window, t], correcting counter
+// resets and wrap-arounds (see CounterOpts).
+//
+// As in Prometheus, the increase observed between the first and last sample
+// of the window is extrapolated towards the start of the window: up to the
+// window edge when the first sample is within 1.1 average sample intervals
+// of it, by half an interval otherwise, and never below the point where the
+// counter would have been zero. Points whose window holds fewer than two
+// samples are StMissing.
+//
+// Errors:
+//   - ErrZeroPeriod if window <= 0.
+//   - ErrBounds if opts.WrapAt < 0.
+//   - ErrUnsorted if Chron is not strictly increasing.
+func (ts *TimeSeries) Increase(window time.Duration, opts CounterOpts) (TimeSeries, error) {
+	return ts.counterWindow(window, opts, false)
+}
+
+// Rate returns the per-second rate of a counter over the trailing window,
+// that is Increase(window, opts) divided by the window length in seconds.
+// It shares the semantics and errors of Increase.
+func (ts *TimeSeries) Rate(window time.Duration, opts CounterOpts) (TimeSeries, error) {
+	return ts.counterWindow(window, opts, true)
+}
+
+// counterWindow implements Increase and Rate.
+func (ts *TimeSeries) counterWindow(window time.Duration, opts CounterOpts, perSecond bool) (TimeSeries, error) {
+	out := TimeSeries{Name: ts.Name}
+	if window <= 0 {
+		return out, ErrZeroPeriod
+	}
+	if opts.WrapAt < 0 {
+		return out, ErrBounds
+	}
+	if !ts.strictlyIncreasing() {
+		return out, ErrUnsorted
+	}
+
+	// Work on the valid points only, with their deltas recomputed.
+	var valid TimeSeries
+	for _, d := range ts.DataSeries {
+		if usable(d, false) {
+			valid.AddDataUnit(d)
+		}
+	}
+	valid.DeltasFiller()
+	ds := valid.DataSeries
+
+	// cum[i] is the reset-corrected growth from ds[0] to ds[i].
+	cum := make([]float64, len(ds))
+	for i := 1; i < len(ds); i++ {
+		inc := ds[i].Dmeas
+		if inc < 0 {
+			if opts.WrapAt > 0 {
+				inc += opts.WrapAt
+			} else {
+				inc = ds[i].Meas
+			}
+		}
+		cum[i] = cum[i-1] + inc
+	}
+
+	j := 0
+	for i, d := range ds {
+		from := d.Chron.Add(-window)
+		for !ds[j].Chron.After(from) {
+			j++
+		}
+		du := DataUnit{Chron: d.Chron, Meas: math.NaN(), Status: StMissing}
+		if i > j {
+			du.Meas = extrapolatedIncrease(ds[j], d, cum[i]-cum[j], i-j, from)
+			du.Status = StOK
+			if perSecond {
+				du.Meas /= window.Seconds()
+			}
+		}
+		out.AddDataUnit(du)
+	}
+	return out, nil
+}
+
+// extrapolatedIncrease scales the growth observed between first and last
+// (intervals sample intervals apart) to the window starting at from, with
+// the Prometheus extrapolation rules. The window ends at last.Chron.
+func extrapolatedIncrease(first, last DataUnit, growth float64, intervals int, from time.Time) float64 {
+	sampled := last.Chron.Sub(first.Chron).Seconds()
+	toStart := first.Chron.Sub(from).Seconds()
+	avg := sampled / float64(intervals)
+
+	// A counter cannot have been below zero before the first sample.
+	if growth > 0 && first.Meas >= 0 {
+		if toZero := sampled * first.Meas / growth; toZero < toStart {
+			toStart = toZero
+		}
+	}
+	extended := sampled
+	if toStart < avg*1.1 {
+		extended += toStart
+	} else {
+		extended += avg / 2
+	}
+	return growth * extended / sampled
+}
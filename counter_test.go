@@ -0,0 +1,91 @@
+package timeseries
+
+import (
+	"testing"
+	"time"
+)
+
+// counterSeries returns one point every 10 s with the given values.
+func counterSeries(vals ...float64) TimeSeries {
+	t0 := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	ts := TimeSeries{Name: "counter"}
+	for i, v := range vals {
+		ts.AddData(t0.Add(time.Duration(i)*10*time.Second), v)
+	}
+	return ts
+}
+
+func TestDetectResets(t *testing.T) {
+	ts := counterSeries(1, 2, 3, 0, 1, 5, 2)
+	ts.DataSeries[5].Status = StInvalid // ignored: 1 -> 2 is not a reset
+	got := ts.DetectResets()
+	if len(got) != 1 || got[0] != 3 {
+		t.Fatalf("resets = %v, want [3]", got)
+	}
+}
+
+func TestIncrease_RegularCounter(t *testing.T) {
+	ts := counterSeries(0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10)
+	inc, err := ts.Increase(time.Minute, CounterOpts{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(inc.DataSeries) != len(ts.DataSeries) {
+		t.Fatalf("got %d points, want %d", len(inc.DataSeries), len(ts.DataSeries))
+	}
+	if inc.DataSeries[0].Status != StMissing {
+		t.Fatalf("single-sample window must be StMissing")
+	}
+	// Window (40s,100s] holds 5..10: growth 5 over 50s, extrapolated to 60s.
+	if got := inc.DataSeries[10].Meas; !almostEq(got, 6, 1e-9) {
+		t.Fatalf("increase at 100s = %v, want 6", got)
+	}
+	rate, err := ts.Rate(time.Minute, CounterOpts{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := rate.DataSeries[10].Meas; !almostEq(got, 0.1, 1e-12) {
+		t.Fatalf("rate at 100s = %v, want 0.1", got)
+	}
+	// Window (-50s,10s] holds 0 and 1: extrapolation stops where the
+	// counter was zero, i.e. at the first sample.
+	if got := inc.DataSeries[1].Meas; !almostEq(got, 1, 1e-9) {
+		t.Fatalf("increase at 10s = %v, want 1", got)
+	}
+}
+
+func TestIncrease_ResetAndWrap(t *testing.T) {
+	// Window (0s,60s] holds 7, 8, 9, reset to 0, 1, 2: growth 4 over 50s.
+	ts := counterSeries(6, 7, 8, 9, 0, 1, 2)
+	inc, err := ts.Increase(time.Minute, CounterOpts{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := inc.DataSeries[6].Meas; !almostEq(got, 4*60.0/50, 1e-9) {
+		t.Fatalf("increase across reset = %v, want %v", got, 4*60.0/50)
+	}
+
+	// 16-bit register: 65534, 65535, 0, 1 grows by 1 each step.
+	ts = counterSeries(65533, 65534, 65535, 0, 1)
+	inc, err = ts.Increase(35*time.Second, CounterOpts{WrapAt: 65536})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := inc.DataSeries[4].Meas; !almostEq(got, 3.5, 1e-9) {
+		t.Fatalf("increase across wrap = %v, want 3.5", got)
+	}
+}
+
+func TestIncrease_Errors(t *testing.T) {
+	ts := counterSeries(1, 2)
+	if _, err := ts.Rate(0, CounterOpts{}); err != ErrZeroPeriod {
+		t.Fatalf("zero window: got %v, want ErrZeroPeriod", err)
+	}
+	if _, err := ts.Rate(time.Minute, CounterOpts{WrapAt: -1}); err != ErrBounds {
+		t.Fatalf("negative WrapAt: got %v, want ErrBounds", err)
+	}
+	ts.DataSeries[1].Chron = ts.DataSeries[0].Chron
+	if _, err := ts.Increase(time.Minute, CounterOpts{}); err != ErrUnsorted {
+		t.Fatalf("unsorted: got %v, want ErrUnsorted", err)
+	}
+}
@@ -0,0 +1,399 @@
+package timeseries
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CSVOpts describes the layout of a CSV file for ReadCSV, ReadCSVWide and
+// WriteCSV. The zero value reads and writes "time,value" rows with RFC 3339
+// timestamps and no header.
+//
+// Fields:
+//   - Comma:        field delimiter. 0 means ',' (';' with DecimalComma).
+//   - HasHeader:    the first record holds the column names.
+//   - ColumnNames:  names given to the columns when there is no header.
+//   - TimeColumn:   name of the timestamp column; "" means the first one.
+//   - ValueColumn:  name of the value column; "" means the first column
+//     that is neither the time nor the status column.
+//   - StatusColumn: name of an optional status column, holding a
+//     StatusCode number or name ("ok", "missing", "outlier", "invalid",
+//     "imputed"). "" means none: values are StOK, NA values StMissing.
+//   - StatusSuffix: for ReadCSVWide, the column "<name><suffix>" holds the
+//     status of column "<name>" (e.g. "_status").
+//   - ValueColumns: for ReadCSVWide, the columns to read; nil means all but
+//     the time and status columns.
+//   - Time:         timestamp encoding.
+//   - Layouts:      time.Parse layouts for TimeLayout, tried in order; the
+//     first one is used for writing.
+//   - Location:     location of layouts without a zone, and of written
+//     layouts. nil means UTC.
+//   - DecimalComma: numbers use ',' as decimal separator (e.g. "3,14").
+//   - NA:           tokens read as missing values. nil means "", "NA",
+//     "N/A", "NaN", "nan", "null" and "NULL". The first token is written for
+//     missing values.
+//   - Name:         name of the series read by ReadCSV, or of the container
+//     read by ReadCSVWide. "" means the value column name.
+type CSVOpts struct {
+	Comma        rune
+	HasHeader    bool
+	ColumnNames  []string
+	TimeColumn   string
+	ValueColumn  string
+	StatusColumn string
+	StatusSuffix string
+	ValueColumns []string
+	Time         TimeEncoding
+	Layouts      []string
+	Location     *time.Location
+	DecimalComma bool
+	NA           []string
+	Name         string
+}
+
+// defaultNA lists the tokens read as missing values by default.
+var defaultNA = []string{"", "NA", "N/A", "NaN", "nan", "null", "NULL"}
+
+// statusNames maps status names to codes, for CSV status columns.
+var statusNames = map[string]StatusCode{
+	"ok": StOK, "missing": StMissing, "outlier": StOutlier, "invalid": StInvalid, "imputed": StImputed,
+}
+
+// normalized returns opts with the defaults filled in.
+func (opts CSVOpts) normalized() (CSVOpts, error) {
+	if opts.Comma == 0 {
+		opts.Comma = ','
+		if opts.DecimalComma {
+			opts.Comma = ';'
+		}
+	}
+	if opts.DecimalComma && opts.Comma == ',' {
+		return opts, ErrBounds
+	}
+	if opts.Time < TimeRFC3339 || opts.Time > TimeLayout || (opts.Time == TimeLayout && len(opts.Layouts) == 0) {
+		return opts, ErrBounds
+	}
+	if opts.Location == nil {
+		opts.Location = time.UTC
+	}
+	if opts.NA == nil {
+		opts.NA = defaultNA
+	}
+	return opts, nil
+}
+
+// csvTable is a CSV file with its column names resolved.
+type csvTable struct {
+	opts    CSVOpts
+	names   []string
+	records [][]string
+	first   int // line number of records[0]
+	timeCol int
+	statCol int // -1 if none
+}
+
+// readTable reads the whole file and resolves the time and status columns.
+func readTable(r io.Reader, opts CSVOpts) (*csvTable, error) {
+	opts, err := opts.normalized()
+	if err != nil {
+		return nil, err
+	}
+	cr := csv.NewReader(r)
+	cr.Comma = opts.Comma
+	cr.TrimLeadingSpace = true
+	records, err := cr.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	tab := &csvTable{opts: opts, names: opts.ColumnNames, records: records, first: 1}
+	if opts.HasHeader {
+		if len(records) == 0 {
+			return nil, ErrEmptyInput
+		}
+		tab.names, tab.records, tab.first = records[0], records[1:], 2
+	}
+	if tab.names == nil && len(tab.records) > 0 {
+		// Unnamed columns are called by their position.
+		for i := range tab.records[0] {
+			tab.names = append(tab.names, strconv.Itoa(i))
+		}
+	}
+	if tab.timeCol, err = tab.column(opts.TimeColumn, 0); err != nil {
+		return nil, err
+	}
+	if tab.statCol, err = tab.column(opts.StatusColumn, -1); err != nil {
+		return nil, err
+	}
+	return tab, nil
+}
+
+// column returns the position of the named column, or def if name is "".
+func (tab *csvTable) column(name string, def int) (int, error) {
+	if name == "" {
+		return def, nil
+	}
+	for i, n := range tab.names {
+		if n == name {
+			return i, nil
+		}
+	}
+	return -1, ErrUnknownSeries
+}
+
+// unit parses the DataUnit of record k from the value column vc and the
+// status column sc (-1 for none).
+func (tab *csvTable) unit(k, vc, sc int) (DataUnit, error) {
+	rec := tab.records[k]
+	line := tab.first + k
+	fail := func(col int, err error) (DataUnit, error) {
+		return DataUnit{}, fmt.Errorf("csv line %d, column %q: %w", line, tab.names[col], err)
+	}
+	for _, c := range []int{tab.timeCol, vc, sc} {
+		if c >= len(rec) {
+			return DataUnit{}, fmt.Errorf("csv line %d: %w", line, ErrSize)
+		}
+	}
+	var du DataUnit
+	var err error
+	if du.Chron, err = tab.opts.parseTime(rec[tab.timeCol]); err != nil {
+		return fail(tab.timeCol, err)
+	}
+	if du.Meas, err = tab.opts.parseValue(rec[vc]); err != nil {
+		return fail(vc, err)
+	}
+	if math.IsNaN(du.Meas) {
+		du.Status = StMissing
+	}
+	if sc >= 0 {
+		if du.Status, err = parseStatus(rec[sc]); err != nil {
+			return fail(sc, err)
+		}
+	}
+	return du, nil
+}
+
+// ReadCSV reads one series from a CSV file laid out as described by opts.
+// Points keep the order of the file (call SortChronoAsc if needed, or check
+// it with QualityRules.Timestamps). Values matching an NA token become NaN
+// with StMissing unless a status column says otherwise.
+//
+// Errors: the csv package errors, ErrUnknownSeries for a column name that
+// is not in the header, ErrBounds for inconsistent options, and parse
+// errors mentioning the line and column.
+func ReadCSV(r io.Reader, opts CSVOpts) (TimeSeries, error) {
+	tab, err := readTable(r, opts)
+	if err != nil {
+		return TimeSeries{}, err
+	}
+	vc := -1
+	if tab.opts.ValueColumn != "" {
+		if vc, err = tab.column(tab.opts.ValueColumn, -1); err != nil {
+			return TimeSeries{}, err
+		}
+	} else {
+		for i := range tab.names {
+			if i != tab.timeCol && i != tab.statCol {
+				vc = i
+				break
+			}
+		}
+	}
+	if vc < 0 {
+		return TimeSeries{}, ErrSize
+	}
+	ts := TimeSeries{Name: tab.opts.Name}
+	if ts.Name == "" {
+		ts.Name = tab.names[vc]
+	}
+	for k := range tab.records {
+		du, err := tab.unit(k, vc, tab.statCol)
+		if err != nil {
+			return TimeSeries{}, err
+		}
+		ts.AddDataUnit(du)
+	}
+	return ts, nil
+}
+
+// ReadCSVWide reads a wide CSV file, one timestamp column and one column
+// per series, into a container whose series are named after the columns.
+// Per-series status columns can be paired through StatusSuffix. It shares
+// the conventions and errors of ReadCSV.
+func ReadCSVWide(r io.Reader, opts CSVOpts) (TsContainer, error) {
+	tsc := NewTsContainer()
+	tab, err := readTable(r, opts)
+	if err != nil {
+		return tsc, err
+	}
+	tsc.Name = tab.opts.Name
+
+	cols := tab.opts.ValueColumns
+	if cols == nil {
+		for i, n := range tab.names {
+			isStatus := tab.opts.StatusSuffix != "" && strings.HasSuffix(n, tab.opts.StatusSuffix)
+			if i != tab.timeCol && i != tab.statCol && !isStatus {
+				cols = append(cols, n)
+			}
+		}
+	}
+	for _, name := range cols {
+		vc, err := tab.column(name, -1)
+		if err != nil {
+			return tsc, err
+		}
+		sc := -1
+		if tab.opts.StatusSuffix != "" {
+			if i, err := tab.column(name+tab.opts.StatusSuffix, -1); err == nil {
+				sc = i
+			}
+		}
+		ts := TimeSeries{Name: name}
+		for k := range tab.records {
+			du, err := tab.unit(k, vc, sc)
+			if err != nil {
+				return tsc, err
+			}
+			ts.AddDataUnit(du)
+		}
+		tsc.Ts[name] = &ts
+	}
+	return tsc, nil
+}
+
+// WriteCSV writes the series as "time,value[,status]" rows laid out as
+// described by opts: the header (when HasHeader) uses TimeColumn,
+// ValueColumn and StatusColumn, or "time" and "value"; a status column is
+// written only if StatusColumn is set, as StatusCode numbers. Missing
+// values are written as the first NA token.
+func (ts *TimeSeries) WriteCSV(w io.Writer, opts CSVOpts) error {
+	opts, err := opts.normalized()
+	if err != nil {
+		return err
+	}
+	cw := csv.NewWriter(w)
+	cw.Comma = opts.Comma
+	if opts.HasHeader {
+		header := []string{opts.TimeColumn, opts.ValueColumn}
+		if header[0] == "" {
+			header[0] = "time"
+		}
+		if header[1] == "" {
+			header[1] = "value"
+		}
+		if opts.StatusColumn != "" {
+			header = append(header, opts.StatusColumn)
+		}
+		if err := cw.Write(header); err != nil {
+			return err
+		}
+	}
+	for _, d := range ts.DataSeries {
+		rec := []string{opts.formatTime(d.Chron), opts.formatValue(d.Meas)}
+		if opts.StatusColumn != "" {
+			rec = append(rec, strconv.Itoa(int(d.Status)))
+		}
+		if err := cw.Write(rec); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// parseTime decodes a timestamp cell.
+func (opts CSVOpts) parseTime(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	switch opts.Time {
+	case TimeUnix, TimeUnixMilli:
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return time.Time{}, err
+		}
+		whole, frac := math.Modf(f)
+		if opts.Time == TimeUnixMilli {
+			return time.UnixMilli(int64(whole)).Add(time.Duration(math.Round(frac * 1e6))).In(opts.Location), nil
+		}
+		return time.Unix(int64(whole), int64(math.Round(frac*1e9))).In(opts.Location), nil
+	case TimeUnixNano:
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return time.Time{}, err
+		}
+		return time.Unix(0, n).In(opts.Location), nil
+	case TimeLayout:
+		var err error
+		for _, layout := range opts.Layouts {
+			var t time.Time
+			if t, err = time.ParseInLocation(layout, s, opts.Location); err == nil {
+				return t, nil
+			}
+		}
+		return time.Time{}, err
+	}
+	return time.Parse(time.RFC3339Nano, s)
+}
+
+// formatTime encodes a timestamp cell.
+func (opts CSVOpts) formatTime(t time.Time) string {
+	switch opts.Time {
+	case TimeUnix:
+		if t.Nanosecond() == 0 {
+			return strconv.FormatInt(t.Unix(), 10)
+		}
+		return strconv.FormatFloat(float64(t.UnixNano())/1e9, 'f', -1, 64)
+	case TimeUnixMilli:
+		return strconv.FormatInt(t.UnixMilli(), 10)
+	case TimeUnixNano:
+		return strconv.FormatInt(t.UnixNano(), 10)
+	case TimeLayout:
+		return t.In(opts.Location).Format(opts.Layouts[0])
+	}
+	return t.Format(time.RFC3339Nano)
+}
+
+// parseValue decodes a value cell; NA tokens give NaN.
+func (opts CSVOpts) parseValue(s string) (float64, error) {
+	s = strings.TrimSpace(s)
+	for _, na := range opts.NA {
+		if s == na {
+			return math.NaN(), nil
+		}
+	}
+	if opts.DecimalComma {
+		s = strings.Replace(s, ",", ".", 1)
+	}
+	return strconv.ParseFloat(s, 64)
+}
+
+// formatValue encodes a value cell; NaN gives the first NA token.
+func (opts CSVOpts) formatValue(v float64) string {
+	if math.IsNaN(v) {
+		return opts.NA[0]
+	}
+	s := strconv.FormatFloat(v, 'g', -1, 64)
+	if opts.DecimalComma {
+		s = strings.Replace(s, ".", ",", 1)
+	}
+	return s
+}
+
+// parseStatus decodes a status cell, by number or name.
+func parseStatus(s string) (StatusCode, error) {
+	s = strings.TrimSpace(s)
+	if st, ok := statusNames[strings.ToLower(s)]; ok {
+		return st, nil
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, err
+	}
+	if n < 0 || n > int(StImputed) {
+		return 0, ErrBounds
+	}
+	return StatusCode(n), nil
+}
@@ -0,0 +1,122 @@
+package timeseries
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReadCSV_HeaderMappingAndStatus(t *testing.T) {
+	in := "site;temp;flag;ts\n" +
+		"a; 21,5;ok;2025-01-01 12:00\n" +
+		"a;NA;0;2025-01-01 12:01\n" +
+		"a;99,25;outlier;2025-01-01 12:02\n"
+	paris, _ := time.LoadLocation("Europe/Paris")
+	ts, err := ReadCSV(strings.NewReader(in), CSVOpts{
+		HasHeader: true, TimeColumn: "ts", ValueColumn: "temp", StatusColumn: "flag",
+		Time: TimeLayout, Layouts: []string{time.RFC3339, "2006-01-02 15:04"}, Location: paris,
+		DecimalComma: true,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ts.Name != "temp" || len(ts.DataSeries) != 3 {
+		t.Fatalf("unexpected series %+v", ts)
+	}
+	if want := time.Date(2025, 1, 1, 11, 0, 0, 0, time.UTC); !ts.DataSeries[0].Chron.Equal(want) {
+		t.Fatalf("time in Paris not honoured: %v", ts.DataSeries[0].Chron)
+	}
+	if ts.DataSeries[0].Meas != 21.5 || ts.DataSeries[2].Meas != 99.25 {
+		t.Fatalf("decimal comma: %v", measSlice(ts))
+	}
+	// The status column wins over the NA rule.
+	if !math.IsNaN(ts.DataSeries[1].Meas) || ts.DataSeries[1].Status != StOK || ts.DataSeries[2].Status != StOutlier {
+		t.Fatalf("unexpected statuses %+v", ts.DataSeries)
+	}
+}
+
+func TestReadCSV_UnixEncodings(t *testing.T) {
+	want := time.Date(2025, 1, 1, 12, 0, 0, 500000000, time.UTC)
+	cases := []struct {
+		enc  TimeEncoding
+		cell string
+	}{
+		{TimeUnix, "1735732800.5"},
+		{TimeUnixMilli, "1735732800500"},
+		{TimeUnixNano, "1735732800500000000"},
+		{TimeRFC3339, "2025-01-01T13:00:00.5+01:00"},
+	}
+	for _, c := range cases {
+		ts, err := ReadCSV(strings.NewReader(c.cell+",1\n"+c.cell+",\n"), CSVOpts{Time: c.enc})
+		if err != nil {
+			t.Fatalf("%v: unexpected error: %v", c.enc, err)
+		}
+		if !ts.DataSeries[0].Chron.Equal(want) {
+			t.Fatalf("%v: got %v, want %v", c.enc, ts.DataSeries[0].Chron, want)
+		}
+		if ts.DataSeries[1].Status != StMissing {
+			t.Fatalf("%v: empty cell must be missing", c.enc)
+		}
+	}
+}
+
+func TestReadCSV_Errors(t *testing.T) {
+	if _, err := ReadCSV(strings.NewReader("time,v\n"), CSVOpts{HasHeader: true, ValueColumn: "x"}); err != ErrUnknownSeries {
+		t.Fatalf("expected ErrUnknownSeries, got %v", err)
+	}
+	if _, err := ReadCSV(strings.NewReader(""), CSVOpts{Comma: ',', DecimalComma: true}); err != ErrBounds {
+		t.Fatalf("expected ErrBounds, got %v", err)
+	}
+	_, err := ReadCSV(strings.NewReader("2025-01-01T00:00:00Z,1\n2025-01-01T00:01:00Z,abc\n"), CSVOpts{})
+	if err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Fatalf("expected an error on line 2, got %v", err)
+	}
+}
+
+func TestReadCSVWide(t *testing.T) {
+	in := "time,p1,p1_st,p2\n" +
+		"2025-01-01T00:00:00Z,1,0,10\n" +
+		"2025-01-01T01:00:00Z,2,3,NA\n"
+	tsc, err := ReadCSVWide(strings.NewReader(in), CSVOpts{HasHeader: true, StatusSuffix: "_st", Name: "plant"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if tsc.Name != "plant" || len(tsc.Ts) != 2 {
+		t.Fatalf("unexpected container %+v", tsc)
+	}
+	p1, p2 := tsc.Ts["p1"], tsc.Ts["p2"]
+	if p1.DataSeries[1].Status != StInvalid || p1.DataSeries[1].Meas != 2 {
+		t.Fatalf("paired status column not read: %+v", p1.DataSeries)
+	}
+	if p2.DataSeries[0].Meas != 10 || p2.DataSeries[1].Status != StMissing {
+		t.Fatalf("unexpected p2 %+v", p2.DataSeries)
+	}
+}
+
+func TestWriteCSV_RoundTrip(t *testing.T) {
+	ts := mkTS(1.5, math.NaN(), -3)
+	ts.DataSeries[1].Status = StMissing
+	ts.DataSeries[2].Status = StImputed
+	opts := CSVOpts{HasHeader: true, StatusColumn: "status", Time: TimeUnixMilli, DecimalComma: true, NA: []string{"NA"}}
+
+	var buf bytes.Buffer
+	if err := ts.WriteCSV(&buf, opts); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := "time;value;status\n1735732800000;1,5;0\n1735732860000;NA;1\n1735732920000;-3;4\n"
+	if buf.String() != want {
+		t.Fatalf("got\n%s\nwant\n%s", buf.String(), want)
+	}
+	back, err := ReadCSV(&buf, opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for i, d := range back.DataSeries {
+		o := ts.DataSeries[i]
+		if !d.Chron.Equal(o.Chron) || d.Status != o.Status || (d.Meas != o.Meas && !math.IsNaN(o.Meas)) {
+			t.Fatalf("row %d: got %+v, want %+v", i, d, o)
+		}
+	}
+}
@@ -0,0 +1,264 @@
+package timeseries
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// Flag records that a detector marked a point as an outlier, so that what
+// was flagged, by what and with which threshold can be audited later.
+//
+// Fields:
+//   - Index:     position of the point in DataSeries.
+//   - Chron:     its timestamp, which survives later reordering.
+//   - Meas:      its value when it was flagged.
+//   - Detector:  the name of the detector (e.g. "zscore").
+//   - Threshold: the limit that was crossed, in the detector's own unit
+//     (a value for bounds and percentiles, a number of deviations for
+//     z-scores, Hampel and MAD, a ratio for Peirce).
+type Flag struct {
+	Index     int
+	Chron     time.Time
+	Meas      float64
+	Detector  string
+	Threshold float64
+}
+
+// Detector finds outliers in a series without modifying it. Detect returns
+// one Flag per outlier, in chronological order. Implementations only judge
+// valid points (finite Meas and StOK) and ignore the others.
+type Detector interface {
+	Detect(ts *TimeSeries) []Flag
+}
+
+// Flag runs the detector and marks every flagged point StOutlier in place
+// (a point already StMissing or StInvalid keeps its status). Unlike the
+// *Cleaning methods the series keeps all its points, so regularization and
+// plots still see where the outliers were. Each flagged point is also
+// annotated with the detector name and threshold (see Annotate). It
+// returns the flags for auditing.
+func (ts *TimeSeries) Flag(d Detector) []Flag {
+	flags := d.Detect(ts)
+	now := time.Now()
+	for _, f := range flags {
+		du := &ts.DataSeries[f.Index]
+		du.Status = worstStatus(du.Status, StOutlier)
+		ts.Annotate(f.Chron, Annotation{
+			Source: f.Detector,
+			Reason: "outlier",
+			Status: StOutlier,
+			Params: map[string]string{"threshold": formatParam(f.Threshold)},
+			At:     now,
+		})
+	}
+	return flags
+}
+
+// newFlags builds the flags of the points at idx.
+func newFlags(ts *TimeSeries, idx []int, detector string, threshold float64) []Flag {
+	sort.Ints(idx)
+	flags := make([]Flag, 0, len(idx))
+	for _, i := range idx {
+		d := ts.DataSeries[i]
+		flags = append(flags, Flag{Index: i, Chron: d.Chron, Meas: d.Meas, Detector: detector, Threshold: threshold})
+	}
+	return flags
+}
+
+// validValues returns the values of the valid points and their positions.
+func validValues(ts *TimeSeries) ([]float64, []int) {
+	var vals []float64
+	var pos []int
+	for i, d := range ts.DataSeries {
+		if usable(d, false) {
+			vals = append(vals, d.Meas)
+			pos = append(pos, i)
+		}
+	}
+	return vals, pos
+}
+
+// BoundsDetector flags the values outside [Min, Max], as RemoveOutbounds.
+type BoundsDetector struct {
+	Min, Max float64
+}
+
+// Detect implements Detector. The threshold is the bound that was crossed.
+func (b BoundsDetector) Detect(ts *TimeSeries) []Flag {
+	var flags []Flag
+	vals, pos := validValues(ts)
+	for k, v := range vals {
+		switch {
+		case v < b.Min:
+			flags = append(flags, newFlags(ts, []int{pos[k]}, "bounds", b.Min)...)
+		case v > b.Max:
+			flags = append(flags, newFlags(ts, []int{pos[k]}, "bounds", b.Max)...)
+		}
+	}
+	return flags
+}
+
+// PercentileDetector flags the values outside the [Perc, 100-Perc]
+// percentile fences, as PercCleaning.
+type PercentileDetector struct {
+	Perc float64
+}
+
+// Detect implements Detector. The threshold is the fence that was crossed.
+func (p PercentileDetector) Detect(ts *TimeSeries) []Flag {
+	vals, _ := validValues(ts)
+	lo, err := Percentile(vals, p.Perc)
+	if err != nil {
+		return nil
+	}
+	hi, err := Percentile(vals, 100-p.Perc)
+	if err != nil {
+		return nil
+	}
+	flags := BoundsDetector{Min: lo, Max: hi}.Detect(ts)
+	for i := range flags {
+		flags[i].Detector = "percentile"
+	}
+	return flags
+}
+
+// ZscoreDetector flags the values farther than Level standard deviations
+// from the mean, as ZscoreCleaning.
+type ZscoreDetector struct {
+	Level float64
+}
+
+// Detect implements Detector. The threshold is Level.
+func (z ZscoreDetector) Detect(ts *TimeSeries) []Flag {
+	vals, pos := validValues(ts)
+	mean, err := Mean(vals)
+	if err != nil {
+		return nil
+	}
+	std, _ := StdDev(vals)
+	var idx []int
+	for k, v := range vals {
+		if math.Abs(v-mean) > z.Level*std {
+			idx = append(idx, pos[k])
+		}
+	}
+	return newFlags(ts, idx, "zscore", z.Level)
+}
+
+// PeirceDetector flags the values rejected by Peirce's criterion, as
+// PeirceOutlierRemoval.
+type PeirceDetector struct{}
+
+// Detect implements Detector. The threshold is PeirceRatio(N, k, 1), k
+// being the number of rejected points.
+func (PeirceDetector) Detect(ts *TimeSeries) []Flag {
+	vals, pos := validValues(ts)
+	if len(vals) < 3 {
+		return nil
+	}
+	rej := Peirce(vals)
+	idx := make([]int, len(rej))
+	for k, r := range rej {
+		idx[k] = pos[r]
+	}
+	threshold := math.NaN()
+	if n := len(rej); n > 0 {
+		threshold = PeirceRatio(len(vals), n, 1)
+	}
+	return newFlags(ts, idx, "peirce", threshold)
+}
+
+// HampelDetector flags the values farther than K scaled MADs from the
+// median of their centered time window, as HampelCleaning.
+type HampelDetector struct {
+	Window time.Duration
+	K      float64
+}
+
+// Detect implements Detector. The threshold is K.
+func (h HampelDetector) Detect(ts *TimeSeries) []Flag {
+	return newFlags(ts, ts.hampelIndices(h.Window, h.K), "hampel", h.K)
+}
+
+// MADDetector flags the values whose modified z-score exceeds Threshold,
+// as MADCleaning.
+type MADDetector struct {
+	Threshold float64
+}
+
+// Detect implements Detector.
+func (m MADDetector) Detect(ts *TimeSeries) []Flag {
+	return newFlags(ts, ts.madIndices(m.Threshold), "mad", m.Threshold)
+}
+
+// testFlags turns the report of a statistical test run on the valid values
+// of ts (at positions pos) into flags. The threshold of a flag is the
+// critical value of the step that examined the point, or of the single
+// step.
+func testFlags(ts *TimeSeries, pos []int, res OutlierTest, err error) []Flag {
+	if err != nil {
+		return nil
+	}
+	var flags []Flag
+	for _, r := range res.Rejected {
+		threshold := res.Critical[0]
+		for step, c := range res.Candidates {
+			if c == r {
+				threshold = res.Critical[step]
+			}
+		}
+		flags = append(flags, newFlags(ts, []int{pos[r]}, res.Test, threshold)...)
+	}
+	sort.Slice(flags, func(i, j int) bool { return flags[i].Index < flags[j].Index })
+	return flags
+}
+
+// GrubbsDetector flags the outlier found by Grubbs' test, if any.
+type GrubbsDetector struct {
+	Alpha float64
+	Tail  Tail
+}
+
+// Detect implements Detector. The threshold is the critical G.
+func (g GrubbsDetector) Detect(ts *TimeSeries) []Flag {
+	vals, pos := validValues(ts)
+	res, err := Grubbs(vals, g.Alpha, g.Tail)
+	return testFlags(ts, pos, res, err)
+}
+
+// GESDDetector flags the outliers found by the generalized ESD test.
+type GESDDetector struct {
+	MaxOutliers int
+	Alpha       float64
+}
+
+// Detect implements Detector. The threshold is the critical λ of the step
+// that removed the point.
+func (g GESDDetector) Detect(ts *TimeSeries) []Flag {
+	vals, pos := validValues(ts)
+	res, err := GESD(vals, g.MaxOutliers, g.Alpha)
+	return testFlags(ts, pos, res, err)
+}
+
+// ChauvenetDetector flags the outliers found by Chauvenet's criterion.
+type ChauvenetDetector struct{}
+
+// Detect implements Detector. The threshold is the critical |z|.
+func (ChauvenetDetector) Detect(ts *TimeSeries) []Flag {
+	vals, pos := validValues(ts)
+	res, err := Chauvenet(vals)
+	return testFlags(ts, pos, res, err)
+}
+
+// DixonDetector flags the outlier found by Dixon's Q test, if any.
+type DixonDetector struct {
+	Alpha float64
+}
+
+// Detect implements Detector. The threshold is the critical Q.
+func (d DixonDetector) Detect(ts *TimeSeries) []Flag {
+	vals, pos := validValues(ts)
+	res, err := DixonQ(vals, d.Alpha)
+	return testFlags(ts, pos, res, err)
+}
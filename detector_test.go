@@ -0,0 +1,65 @@
+package timeseries
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFlag_KeepsSeriesIntact(t *testing.T) {
+	ts := mkTS(10, 11, 10, 12, 11, 13, 10, 11, 250, 12, 11, 10)
+	ts.DataSeries[3].Status = StInvalid
+	flags := ts.Flag(MADDetector{Threshold: 3.5})
+	if len(flags) != 1 {
+		t.Fatalf("got %d flags, want 1", len(flags))
+	}
+	f := flags[0]
+	if f.Index != 8 || f.Meas != 250 || f.Detector != "mad" || f.Threshold != 3.5 || !f.Chron.Equal(ts.DataSeries[8].Chron) {
+		t.Fatalf("unexpected flag %+v", f)
+	}
+	if len(ts.DataSeries) != 12 || ts.DataSeries[8].Status != StOutlier {
+		t.Fatalf("series must keep its points and flag in place")
+	}
+	if ts.DataSeries[3].Status != StInvalid {
+		t.Fatal("an invalid point must stay invalid")
+	}
+}
+
+func TestDetectors_MatchCleaners(t *testing.T) {
+	build := func() TimeSeries { return mkTS(1, 2, 3, 4, 3, 2, 3, 4, 40, 3, -30, 2) }
+	cases := []struct {
+		det  Detector
+		name string
+		want []int
+	}{
+		{BoundsDetector{Min: 0, Max: 10}, "bounds", []int{8, 10}},
+		{ZscoreDetector{Level: 1.5}, "zscore", []int{8, 10}},
+		{HampelDetector{Window: 7 * time.Minute, K: 3}, "hampel", []int{8, 10}},
+		{MADDetector{Threshold: 3.5}, "mad", []int{8, 10}},
+		{PercentileDetector{Perc: 10}, "percentile", []int{8}},
+	}
+	for _, c := range cases {
+		ts := build()
+		flags := c.det.Detect(&ts)
+		if len(flags) != len(c.want) {
+			t.Fatalf("%s: got %+v, want indices %v", c.name, flags, c.want)
+		}
+		for k, f := range flags {
+			if f.Index != c.want[k] || f.Detector != c.name {
+				t.Fatalf("%s: flag %d is %+v", c.name, k, f)
+			}
+		}
+		for _, d := range ts.DataSeries {
+			if d.Status != StOK {
+				t.Fatalf("%s: Detect must not modify the series", c.name)
+			}
+		}
+	}
+}
+
+func TestBoundsDetector_Threshold(t *testing.T) {
+	ts := mkTS(-5, 0, 15)
+	flags := ts.Flag(BoundsDetector{Min: -1, Max: 10})
+	if len(flags) != 2 || flags[0].Threshold != -1 || flags[1].Threshold != 10 {
+		t.Fatalf("got %+v", flags)
+	}
+}
@@ -21,7 +21,8 @@
 //     accuracy and filling gaps in a controlled way.
 //
 //   - Robust outlier detection and cleaning using several statistical methods
-//     (IQR, z-score, rolling deviation, or custom filters).
+//     (IQR, z-score, Hampel filter on a rolling median/MAD, modified
+//     z-score, or custom filters).
 //
 //   - Statistical summaries (mean, median, standard deviation, percentiles, etc.)
 //     that automatically ignore missing or invalid entries.
@@ -39,7 +40,7 @@
 // Typical usage:
 //
 //	ts := timeseries.BulkSimul("demo", start, time.Hour, 24, 20.0, 3.0, time.Minute)
-//	ts = ts.Regularize(time.Hour)
+//	hourly, err := ts.RegularizeWith(timeseries.RegularizeOpts{Period: time.Hour, Agg: timeseries.AggMean})
 //	ts.CleanOutliers(timeseries.MethodIQR)
 //	mean, _ := ts.Mean()
 package timeseries
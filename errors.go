@@ -32,4 +32,16 @@ var (
 	ErrInfValue = statsError{"Value is infinite."}
 	// ErrYCoord Y Value must be greater than zero
 	ErrYCoord = statsError{"Y Value must be greater than zero."}
+	// ErrZeroPeriod Period must be strictly positive
+	ErrZeroPeriod = statsError{"Period must be strictly positive."}
+	// ErrUnsorted Chron must be strictly increasing
+	ErrUnsorted = statsError{"Chron must be strictly increasing."}
+	// ErrAnchorOutOfRange Anchor is too far from the data
+	ErrAnchorOutOfRange = statsError{"Anchor is too far from the data."}
+	// ErrUnaligned Series must share the same timestamps
+	ErrUnaligned = statsError{"Series must share the same timestamps."}
+	// ErrUnknownSeries No series or column with that name
+	ErrUnknownSeries = statsError{"No series or column with that name."}
+	// ErrCorrupt Encoded data is malformed
+	ErrCorrupt = statsError{"Encoded data is malformed."}
 )
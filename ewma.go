@@ -0,0 +1,113 @@
+package timeseries
+
+import (
+	"math"
+	"time"
+)
+
+// EWMAOpts configures EWMA and EWMVar.
+//
+// Fields:
+//   - HalfLife: time after which the weight of an observation is halved;
+//     must be > 0. An observation of age a weighs 2^(-a/HalfLife), so a
+//     long gap between two samples decays the past more than a short one.
+//   - Adjust:   bias correction. When set, the average is the weighted mean
+//     of all observations so far, normalized by the sum of their weights,
+//     and the variance gets the reliability-weights correction
+//     W²/(W²-ΣW²). When unset, the classical recursion
+//     m = m + α(x - m), with α = 1 - 2^(-Δt/HalfLife), is started at the
+//     first observation and the variance is the biased recursive one.
+//   - OnlyOK:   use only StOK observations. By default every finite value
+//     whose status is neither StMissing nor StInvalid is used.
+type EWMAOpts struct {
+	HalfLife time.Duration
+	Adjust   bool
+	OnlyOK   bool
+}
+
+// EWMA returns the exponentially weighted moving average of the series,
+// one point per input point. The decay applied between two observations
+// depends on the time elapsed between them, so irregular sampling is
+// handled correctly. Skipped points (see EWMAOpts.OnlyOK) take the current
+// average; points before the first used observation are StMissing.
+//
+// Errors:
+//   - ErrZeroPeriod if HalfLife <= 0.
+//   - ErrUnsorted if Chron is not strictly increasing.
+func (ts *TimeSeries) EWMA(opts EWMAOpts) (TimeSeries, error) {
+	mean, _, err := ts.ewm(opts)
+	return mean, err
+}
+
+// EWMVar returns the exponentially weighted moving variance of the series,
+// with the same weighting and semantics as EWMA. Points where it is not
+// defined (fewer than two observations when Adjust is set) are StMissing.
+// The square root gives the band of an EWMA control chart.
+func (ts *TimeSeries) EWMVar(opts EWMAOpts) (TimeSeries, error) {
+	_, variance, err := ts.ewm(opts)
+	return variance, err
+}
+
+// ewm computes the exponentially weighted mean and variance together.
+func (ts *TimeSeries) ewm(opts EWMAOpts) (TimeSeries, TimeSeries, error) {
+	mean := TimeSeries{Name: ts.Name}
+	variance := TimeSeries{Name: ts.Name}
+	if opts.HalfLife <= 0 {
+		return mean, variance, ErrZeroPeriod
+	}
+	if !ts.strictlyIncreasing() {
+		return mean, variance, ErrUnsorted
+	}
+
+	// Adjusted form: sums of weights, squared weights, weighted values and
+	// weighted squares, all decayed to the last used observation.
+	var w, w2, wx, wxx float64
+	// Recursive form.
+	var m, v float64
+	var last time.Time
+	seen := 0
+	h := opts.HalfLife.Seconds()
+	for _, d := range ts.DataSeries {
+		use := isKnot(d)
+		if opts.OnlyOK {
+			use = usable(d, false)
+		}
+		if use {
+			decay := 0.0
+			if seen > 0 {
+				decay = math.Exp2(-d.Chron.Sub(last).Seconds() / h)
+			}
+			w, w2 = w*decay+1, w2*decay*decay+1
+			wx, wxx = wx*decay+d.Meas, wxx*decay+d.Meas*d.Meas
+			if seen == 0 {
+				m = d.Meas
+			} else {
+				alpha := 1 - decay
+				delta := d.Meas - m
+				m += alpha * delta
+				v = (1 - alpha) * (v + alpha*delta*delta)
+			}
+			last = d.Chron
+			seen++
+		}
+
+		mu := DataUnit{Chron: d.Chron, Meas: math.NaN(), Status: StMissing}
+		va := mu
+		if seen > 0 {
+			mu.Status = StOK
+			va.Status = StOK
+			if opts.Adjust {
+				mu.Meas = wx / w
+				va.Meas = math.Max(wxx/w-mu.Meas*mu.Meas, 0) * w * w / (w*w - w2)
+			} else {
+				mu.Meas, va.Meas = m, v
+			}
+			if math.IsNaN(va.Meas) || math.IsInf(va.Meas, 0) {
+				va.Meas, va.Status = math.NaN(), StMissing
+			}
+		}
+		mean.AddDataUnit(mu)
+		variance.AddDataUnit(va)
+	}
+	return mean, variance, nil
+}
@@ -0,0 +1,80 @@
+package timeseries
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestEWMA_AdjustedAndRecursive(t *testing.T) {
+	t0 := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	var ts TimeSeries
+	ts.AddData(t0, 0)
+	ts.AddData(t0.Add(time.Hour), 2)
+
+	adj := EWMAOpts{HalfLife: time.Hour, Adjust: true}
+	mean, err := ts.EWMA(adj)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// Weights 0.5 and 1.
+	if mean.DataSeries[0].Meas != 0 || !almostEq(mean.DataSeries[1].Meas, 4.0/3, 1e-12) {
+		t.Fatalf("adjusted mean: got %+v", mean.DataSeries)
+	}
+	v, _ := ts.EWMVar(adj)
+	if v.DataSeries[0].Status != StMissing || !almostEq(v.DataSeries[1].Meas, 2, 1e-12) {
+		t.Fatalf("adjusted variance: got %+v", v.DataSeries)
+	}
+
+	rec := EWMAOpts{HalfLife: time.Hour}
+	mean, _ = ts.EWMA(rec)
+	v, _ = ts.EWMVar(rec)
+	if mean.DataSeries[1].Meas != 1 || v.DataSeries[1].Meas != 1 {
+		t.Fatalf("recursive: mean %v var %v, want 1 and 1", mean.DataSeries[1].Meas, v.DataSeries[1].Meas)
+	}
+}
+
+func TestEWMA_IrregularGap(t *testing.T) {
+	t0 := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	var ts TimeSeries
+	ts.AddData(t0, 0)
+	ts.AddData(t0.Add(2*time.Hour), 4)
+	mean, _ := ts.EWMA(EWMAOpts{HalfLife: time.Hour})
+	// Two half-lives: α = 0.75.
+	if mean.DataSeries[1].Meas != 3 {
+		t.Fatalf("got %v, want 3", mean.DataSeries[1].Meas)
+	}
+}
+
+func TestEWMA_OnlyOK(t *testing.T) {
+	ts := mkTS(math.NaN(), 10, 100, 10)
+	ts.DataSeries[0].Status = StMissing
+	ts.DataSeries[2].Status = StOutlier
+	mean, err := ts.EWMA(EWMAOpts{HalfLife: time.Minute, OnlyOK: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if mean.DataSeries[0].Status != StMissing {
+		t.Fatalf("leading missing point must stay StMissing, got %v", mean.DataSeries[0].Status)
+	}
+	for i := 1; i < 4; i++ {
+		if mean.DataSeries[i].Meas != 10 {
+			t.Fatalf("point %d: got %v, want 10", i, mean.DataSeries[i].Meas)
+		}
+	}
+	mean, _ = ts.EWMA(EWMAOpts{HalfLife: time.Minute})
+	if mean.DataSeries[2].Meas != 55 {
+		t.Fatalf("outlier must count by default: got %v, want 55", mean.DataSeries[2].Meas)
+	}
+}
+
+func TestEWMA_Errors(t *testing.T) {
+	ts := mkTS(1, 2)
+	if _, err := ts.EWMA(EWMAOpts{}); err != ErrZeroPeriod {
+		t.Fatalf("got %v, want ErrZeroPeriod", err)
+	}
+	ts.DataSeries[1].Chron = ts.DataSeries[0].Chron
+	if _, err := ts.EWMVar(EWMAOpts{HalfLife: time.Minute}); err != ErrUnsorted {
+		t.Fatalf("got %v, want ErrUnsorted", err)
+	}
+}
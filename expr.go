@@ -0,0 +1,755 @@
+package timeseries
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Expr is a parsed derived-series expression such as
+//
+//	net = load - pv * 0.97
+//	cop = if(elec > 0.1, heat / elec, 0)
+//	smooth = rolling_mean(abs(flow), 1h)
+//
+// Operands are series names of a TsContainer, numbers and duration literals
+// (500ms, 30s, 15m, 1h, 1d, 1h30m). Series names are identifiers made of
+// letters, digits, '_' and '.', or any text between backquotes.
+//
+// Operators, by increasing precedence:
+//
+//	||
+//	&&
+//	== != < <= > >=
+//	+ -
+//	* / %
+//	unary - and !
+//	^ (right associative)
+//
+// Comparisons and logical operators yield 1 (true) or 0 (false); any
+// non-zero value is true. Built-in functions:
+//
+//	abs(x) sqrt(x) exp(x) log(x)
+//	min(x, y) max(x, y) clip(x, lo, hi)
+//	if(cond, then, else)
+//	rolling_mean(x, window)   trailing mean over (t-window, t], see Rolling
+//	shift(x, d)               move timestamps by d (d may be negative)
+//
+// Whenever several series meet in one operation they are aligned according
+// to EvalOpts.Join and each result point gets the worst StatusCode of its
+// operands, as with Combine.
+type Expr struct {
+	// Target is the name on the left of '=', or "" for a bare expression.
+	Target string
+	src    string
+	root   exprNode
+}
+
+// EvalOpts configures the evaluation of an Expr.
+//
+// Fields:
+//   - Join: how operands that are series are matched on time. The zero
+//     value is JoinExact (only timestamps shared by all operands).
+type EvalOpts struct {
+	Join JoinMode
+}
+
+// ExprError reports a syntax or evaluation error in an expression, with
+// the byte offset in the source at which it was detected.
+type ExprError struct {
+	Pos int
+	Msg string
+}
+
+func (e *ExprError) Error() string {
+	return fmt.Sprintf("expression: %s at offset %d", e.Msg, e.Pos)
+}
+
+// ParseExpr parses src, either "target = expression" or a bare expression.
+// It returns an *ExprError on a syntax error.
+func ParseExpr(src string) (*Expr, error) {
+	toks, err := lexExpr(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &exprParser{toks: toks}
+	e := &Expr{src: src}
+	if len(toks) > 2 && toks[0].kind == tokIdent && toks[1].kind == tokOp && toks[1].text == "=" {
+		e.Target = toks[0].text
+		p.pos = 2
+	}
+	if e.root, err = p.parseOr(); err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokEOF {
+		return nil, &ExprError{Pos: t.pos, Msg: fmt.Sprintf("unexpected %q", t.text)}
+	}
+	return e, nil
+}
+
+// String returns the source the expression was parsed from.
+func (e *Expr) String() string {
+	return e.src
+}
+
+// Refs returns the names of the series the expression reads, in order of
+// first appearance. It lets configuration loaders check their inputs.
+func (e *Expr) Refs() []string {
+	var refs []string
+	seen := map[string]bool{}
+	var walk func(n exprNode)
+	walk = func(n exprNode) {
+		switch n := n.(type) {
+		case *refNode:
+			if !seen[n.name] {
+				seen[n.name] = true
+				refs = append(refs, n.name)
+			}
+		case *unaryNode:
+			walk(n.x)
+		case *binaryNode:
+			walk(n.x)
+			walk(n.y)
+		case *callNode:
+			for _, a := range n.args {
+				walk(a)
+			}
+		}
+	}
+	walk(e.root)
+	return refs
+}
+
+// Eval evaluates the expression against the series of tsc and returns the
+// resulting series, named after Target. The container is not modified.
+//
+// Errors:
+//   - an error wrapping ErrUnknownSeries if a referenced series is absent.
+//   - an *ExprError on type errors (e.g. a duration used as a number) or
+//     when the expression does not produce a series.
+//   - any alignment error (ErrUnsorted, ...).
+func (e *Expr) Eval(tsc *TsContainer, opts EvalOpts) (TimeSeries, error) {
+	v, err := e.root.eval(tsc, opts)
+	if err != nil {
+		return TimeSeries{}, err
+	}
+	if v.kind != valSeries {
+		return TimeSeries{}, &ExprError{Pos: 0, Msg: "expression does not produce a series"}
+	}
+	// A bare reference evaluates to the operand itself: copy so that the
+	// result never shares memory with a series of the container.
+	out := TimeSeries{Name: e.Target, Comment: e.src, DataSeries: append([]DataUnit(nil), v.ts.DataSeries...)}
+	return out, nil
+}
+
+// Eval parses and evaluates src, which must be of the form
+// "target = expression", and stores the result in tsc.Ts[target],
+// replacing any series of that name. See Expr for the language.
+func (tsc *TsContainer) Eval(src string, opts EvalOpts) (*TimeSeries, error) {
+	e, err := ParseExpr(src)
+	if err != nil {
+		return nil, err
+	}
+	if e.Target == "" {
+		return nil, &ExprError{Pos: 0, Msg: "missing \"target =\""}
+	}
+	ts, err := e.Eval(tsc, opts)
+	if err != nil {
+		return nil, err
+	}
+	if tsc.Ts == nil {
+		tsc.Ts = make(map[string]*TimeSeries)
+	}
+	tsc.Ts[e.Target] = &ts
+	return &ts, nil
+}
+
+// ---------------------------------------------------------------- lexer --
+
+type tokKind int
+
+const (
+	tokEOF tokKind = iota
+	tokNum
+	tokDur
+	tokIdent
+	tokOp
+)
+
+type token struct {
+	kind tokKind
+	text string
+	pos  int
+	num  float64
+	dur  time.Duration
+}
+
+// lexExpr splits src into tokens.
+func lexExpr(src string) ([]token, error) {
+	var toks []token
+	i := 0
+	for i < len(src) {
+		c := rune(src[i])
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case c >= '0' && c <= '9' || c == '.' && i+1 < len(src) && src[i+1] >= '0' && src[i+1] <= '9':
+			start := i
+			for i < len(src) && (isDigit(src[i]) || src[i] == '.') {
+				i++
+			}
+			// Exponent, but not the start of a unit such as "15m".
+			if i+1 < len(src) && (src[i] == 'e' || src[i] == 'E') &&
+				(isDigit(src[i+1]) || (src[i+1] == '+' || src[i+1] == '-') && i+2 < len(src) && isDigit(src[i+2])) {
+				i += 2
+				for i < len(src) && isDigit(src[i]) {
+					i++
+				}
+			}
+			if i < len(src) && unicode.IsLetter(rune(src[i])) {
+				// Duration literal: digits and unit letters glued together.
+				for i < len(src) && (isDigit(src[i]) || src[i] == '.' || unicode.IsLetter(rune(src[i]))) {
+					i++
+				}
+				d, err := parseDurationLit(src[start:i])
+				if err != nil {
+					return nil, &ExprError{Pos: start, Msg: fmt.Sprintf("bad duration %q", src[start:i])}
+				}
+				toks = append(toks, token{kind: tokDur, text: src[start:i], pos: start, dur: d})
+				continue
+			}
+			f, err := strconv.ParseFloat(src[start:i], 64)
+			if err != nil {
+				return nil, &ExprError{Pos: start, Msg: fmt.Sprintf("bad number %q", src[start:i])}
+			}
+			toks = append(toks, token{kind: tokNum, text: src[start:i], pos: start, num: f})
+		case unicode.IsLetter(c) || c == '_':
+			start := i
+			for i < len(src) && (unicode.IsLetter(rune(src[i])) || isDigit(src[i]) || src[i] == '_' || src[i] == '.') {
+				i++
+			}
+			toks = append(toks, token{kind: tokIdent, text: src[start:i], pos: start})
+		case c == '`':
+			end := strings.IndexByte(src[i+1:], '`')
+			if end < 0 {
+				return nil, &ExprError{Pos: i, Msg: "unterminated quoted name"}
+			}
+			toks = append(toks, token{kind: tokIdent, text: src[i+1 : i+1+end], pos: i})
+			i += end + 2
+		default:
+			start := i
+			op := string(c)
+			if i+1 < len(src) {
+				switch two := src[i : i+2]; two {
+				case "<=", ">=", "==", "!=", "&&", "||":
+					op = two
+				}
+			}
+			if len(op) == 1 && !strings.ContainsRune("+-*/%^()<>=!,", c) {
+				return nil, &ExprError{Pos: start, Msg: fmt.Sprintf("unexpected character %q", op)}
+			}
+			i += len(op)
+			toks = append(toks, token{kind: tokOp, text: op, pos: start})
+		}
+	}
+	return append(toks, token{kind: tokEOF, text: "end of expression", pos: len(src)}), nil
+}
+
+func isDigit(b byte) bool {
+	return b >= '0' && b <= '9'
+}
+
+// parseDurationLit parses a Go duration, extended with a leading day count
+// ("1d", "2d12h").
+func parseDurationLit(s string) (time.Duration, error) {
+	var days time.Duration
+	if k := strings.IndexByte(s, 'd'); k > 0 {
+		n, err := strconv.ParseFloat(s[:k], 64)
+		if err != nil {
+			return 0, err
+		}
+		days = time.Duration(n * float64(24*time.Hour))
+		if s = s[k+1:]; s == "" {
+			return days, nil
+		}
+	}
+	d, err := time.ParseDuration(s)
+	return days + d, err
+}
+
+// --------------------------------------------------------------- parser --
+
+type exprParser struct {
+	toks []token
+	pos  int
+}
+
+func (p *exprParser) peek() token {
+	return p.toks[p.pos]
+}
+
+func (p *exprParser) next() token {
+	t := p.toks[p.pos]
+	if t.kind != tokEOF {
+		p.pos++
+	}
+	return t
+}
+
+// accept consumes the next token if it is one of the operators ops.
+func (p *exprParser) accept(ops ...string) (token, bool) {
+	t := p.peek()
+	if t.kind != tokOp {
+		return t, false
+	}
+	for _, op := range ops {
+		if t.text == op {
+			return p.next(), true
+		}
+	}
+	return t, false
+}
+
+func (p *exprParser) expect(op string) error {
+	if _, ok := p.accept(op); !ok {
+		t := p.peek()
+		return &ExprError{Pos: t.pos, Msg: fmt.Sprintf("expected %q, got %q", op, t.text)}
+	}
+	return nil
+}
+
+// binaryLevel parses a left-associative chain of ops over operands parsed
+// by sub.
+func (p *exprParser) binaryLevel(sub func() (exprNode, error), ops ...string) (exprNode, error) {
+	x, err := sub()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		t, ok := p.accept(ops...)
+		if !ok {
+			return x, nil
+		}
+		y, err := sub()
+		if err != nil {
+			return nil, err
+		}
+		x = &binaryNode{op: t.text, pos: t.pos, x: x, y: y}
+	}
+}
+
+func (p *exprParser) parseOr() (exprNode, error) {
+	return p.binaryLevel(p.parseAnd, "||")
+}
+
+func (p *exprParser) parseAnd() (exprNode, error) {
+	return p.binaryLevel(p.parseCmp, "&&")
+}
+
+func (p *exprParser) parseCmp() (exprNode, error) {
+	return p.binaryLevel(p.parseAdd, "==", "!=", "<", "<=", ">", ">=")
+}
+
+func (p *exprParser) parseAdd() (exprNode, error) {
+	return p.binaryLevel(p.parseMul, "+", "-")
+}
+
+func (p *exprParser) parseMul() (exprNode, error) {
+	return p.binaryLevel(p.parseUnary, "*", "/", "%")
+}
+
+func (p *exprParser) parseUnary() (exprNode, error) {
+	if t, ok := p.accept("-", "!"); ok {
+		x, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &unaryNode{op: t.text, pos: t.pos, x: x}, nil
+	}
+	return p.parsePow()
+}
+
+func (p *exprParser) parsePow() (exprNode, error) {
+	x, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	if t, ok := p.accept("^"); ok {
+		y, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &binaryNode{op: "^", pos: t.pos, x: x, y: y}, nil
+	}
+	return x, nil
+}
+
+func (p *exprParser) parsePrimary() (exprNode, error) {
+	t := p.next()
+	switch t.kind {
+	case tokNum:
+		return &numNode{v: t.num}, nil
+	case tokDur:
+		return &durNode{d: t.dur, pos: t.pos}, nil
+	case tokIdent:
+		if _, ok := p.accept("("); !ok {
+			return &refNode{name: t.text, pos: t.pos}, nil
+		}
+		call := &callNode{name: t.text, pos: t.pos}
+		if _, ok := p.accept(")"); ok {
+			return call, nil
+		}
+		for {
+			a, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			call.args = append(call.args, a)
+			if _, ok := p.accept(","); !ok {
+				break
+			}
+		}
+		return call, p.expect(")")
+	case tokOp:
+		if t.text == "(" {
+			x, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			return x, p.expect(")")
+		}
+	}
+	return nil, &ExprError{Pos: t.pos, Msg: fmt.Sprintf("unexpected %q", t.text)}
+}
+
+// ------------------------------------------------------------ evaluator --
+
+type valKind int
+
+const (
+	valNum valKind = iota
+	valDur
+	valSeries
+)
+
+// exprValue is the result of evaluating a node: a scalar, a duration or a
+// series.
+type exprValue struct {
+	kind valKind
+	num  float64
+	dur  time.Duration
+	ts   *TimeSeries
+}
+
+type exprNode interface {
+	eval(tsc *TsContainer, opts EvalOpts) (exprValue, error)
+}
+
+type numNode struct {
+	v float64
+}
+
+type durNode struct {
+	d   time.Duration
+	pos int
+}
+
+type refNode struct {
+	name string
+	pos  int
+}
+
+type unaryNode struct {
+	op  string
+	pos int
+	x   exprNode
+}
+
+type binaryNode struct {
+	op   string
+	pos  int
+	x, y exprNode
+}
+
+type callNode struct {
+	name string
+	pos  int
+	args []exprNode
+}
+
+func (n *numNode) eval(*TsContainer, EvalOpts) (exprValue, error) {
+	return exprValue{kind: valNum, num: n.v}, nil
+}
+
+func (n *durNode) eval(*TsContainer, EvalOpts) (exprValue, error) {
+	return exprValue{kind: valDur, dur: n.d}, nil
+}
+
+func (n *refNode) eval(tsc *TsContainer, _ EvalOpts) (exprValue, error) {
+	ts, ok := tsc.Ts[n.name]
+	if !ok || ts == nil {
+		return exprValue{}, fmt.Errorf("%w: %q at offset %d", ErrUnknownSeries, n.name, n.pos)
+	}
+	return exprValue{kind: valSeries, ts: ts}, nil
+}
+
+func (n *unaryNode) eval(tsc *TsContainer, opts EvalOpts) (exprValue, error) {
+	x, err := n.x.eval(tsc, opts)
+	if err != nil {
+		return x, err
+	}
+	fn := func(a ...float64) float64 { return -a[0] }
+	if n.op == "!" {
+		fn = func(a ...float64) float64 { return boolFloat(!truthy(a[0])) }
+	}
+	return elementwise(n.pos, opts, fn, x)
+}
+
+func (n *binaryNode) eval(tsc *TsContainer, opts EvalOpts) (exprValue, error) {
+	x, err := n.x.eval(tsc, opts)
+	if err != nil {
+		return x, err
+	}
+	y, err := n.y.eval(tsc, opts)
+	if err != nil {
+		return y, err
+	}
+	var fn func(a ...float64) float64
+	switch n.op {
+	case "+":
+		fn = func(a ...float64) float64 { return a[0] + a[1] }
+	case "-":
+		fn = func(a ...float64) float64 { return a[0] - a[1] }
+	case "*":
+		fn = func(a ...float64) float64 { return a[0] * a[1] }
+	case "/":
+		fn = func(a ...float64) float64 { return a[0] / a[1] }
+	case "%":
+		fn = func(a ...float64) float64 { return math.Mod(a[0], a[1]) }
+	case "^":
+		fn = func(a ...float64) float64 { return math.Pow(a[0], a[1]) }
+	case "==":
+		fn = compare(func(a, b float64) bool { return a == b })
+	case "!=":
+		fn = compare(func(a, b float64) bool { return a != b })
+	case "<":
+		fn = compare(func(a, b float64) bool { return a < b })
+	case "<=":
+		fn = compare(func(a, b float64) bool { return a <= b })
+	case ">":
+		fn = compare(func(a, b float64) bool { return a > b })
+	case ">=":
+		fn = compare(func(a, b float64) bool { return a >= b })
+	case "&&":
+		fn = compare(func(a, b float64) bool { return truthy(a) && truthy(b) })
+	case "||":
+		fn = compare(func(a, b float64) bool { return truthy(a) || truthy(b) })
+	}
+	return elementwise(n.pos, opts, fn, x, y)
+}
+
+func (n *callNode) eval(tsc *TsContainer, opts EvalOpts) (exprValue, error) {
+	args := make([]exprValue, len(n.args))
+	for i, a := range n.args {
+		v, err := a.eval(tsc, opts)
+		if err != nil {
+			return v, err
+		}
+		args[i] = v
+	}
+	arity := func(k int) error {
+		if len(args) != k {
+			return &ExprError{Pos: n.pos, Msg: fmt.Sprintf("%s expects %d arguments, got %d", n.name, k, len(args))}
+		}
+		return nil
+	}
+	unary := map[string]func(float64) float64{"abs": math.Abs, "sqrt": math.Sqrt, "exp": math.Exp, "log": math.Log}
+	if f, ok := unary[n.name]; ok {
+		if err := arity(1); err != nil {
+			return exprValue{}, err
+		}
+		return elementwise(n.pos, opts, func(a ...float64) float64 { return f(a[0]) }, args...)
+	}
+
+	switch n.name {
+	case "min", "max":
+		if err := arity(2); err != nil {
+			return exprValue{}, err
+		}
+		f := math.Min
+		if n.name == "max" {
+			f = math.Max
+		}
+		return elementwise(n.pos, opts, func(a ...float64) float64 { return f(a[0], a[1]) }, args...)
+	case "clip":
+		if err := arity(3); err != nil {
+			return exprValue{}, err
+		}
+		return elementwise(n.pos, opts, func(a ...float64) float64 { return math.Max(a[1], math.Min(a[2], a[0])) }, args...)
+	case "if":
+		if err := arity(3); err != nil {
+			return exprValue{}, err
+		}
+		return elementwise(n.pos, opts, func(a ...float64) float64 {
+			switch {
+			case math.IsNaN(a[0]):
+				return math.NaN()
+			case truthy(a[0]):
+				return a[1]
+			}
+			return a[2]
+		}, args...)
+	case "rolling_mean", "shift":
+		if err := arity(2); err != nil {
+			return exprValue{}, err
+		}
+		if args[0].kind != valSeries || args[1].kind != valDur {
+			return exprValue{}, &ExprError{Pos: n.pos, Msg: n.name + " expects (series, duration)"}
+		}
+		if n.name == "shift" {
+			out := shiftSeries(args[0].ts, args[1].dur)
+			return exprValue{kind: valSeries, ts: &out}, nil
+		}
+		out, err := args[0].ts.Rolling(args[1].dur, RollingOpts{Stat: RollMean})
+		if err != nil {
+			return exprValue{}, err
+		}
+		return exprValue{kind: valSeries, ts: &out}, nil
+	}
+	return exprValue{}, &ExprError{Pos: n.pos, Msg: fmt.Sprintf("unknown function %q", n.name)}
+}
+
+func truthy(v float64) bool {
+	return v != 0 && !math.IsNaN(v)
+}
+
+func boolFloat(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// compare lifts a predicate to a 0/1 valued operator; NaN operands give NaN.
+func compare(pred func(a, b float64) bool) func(a ...float64) float64 {
+	return func(a ...float64) float64 {
+		if math.IsNaN(a[0]) || math.IsNaN(a[1]) {
+			return math.NaN()
+		}
+		return boolFloat(pred(a[0], a[1]))
+	}
+}
+
+// elementwise applies fn to scalar and series operands. Scalars broadcast;
+// series are aligned with opts.Join and statuses propagate as in Combine.
+func elementwise(pos int, opts EvalOpts, fn func(a ...float64) float64, args ...exprValue) (exprValue, error) {
+	var series []*TimeSeries
+	for _, a := range args {
+		switch a.kind {
+		case valDur:
+			return exprValue{}, &ExprError{Pos: pos, Msg: "duration used as a number"}
+		case valSeries:
+			series = append(series, a.ts)
+		}
+	}
+	vals := make([]float64, len(args))
+	if len(series) == 0 {
+		for i, a := range args {
+			vals[i] = a.num
+		}
+		return exprValue{kind: valNum, num: fn(vals...)}, nil
+	}
+
+	cols, err := alignOperands(series, opts.Join)
+	if err != nil {
+		return exprValue{}, err
+	}
+	out := TimeSeries{}
+	sts := make([]StatusCode, len(series))
+	for r := range cols[0] {
+		k := 0
+		for i, a := range args {
+			if a.kind == valNum {
+				vals[i] = a.num
+				continue
+			}
+			vals[i], sts[k] = cols[k][r].Meas, cols[k][r].Status
+			k++
+		}
+		du := DataUnit{Chron: cols[0][r].Chron, Meas: fn(vals...), Status: worstStatus(sts...)}
+		if math.IsNaN(du.Meas) {
+			du.Status = worstStatus(du.Status, StMissing)
+		}
+		out.AddDataUnit(du)
+	}
+	return exprValue{kind: valSeries, ts: &out}, nil
+}
+
+// alignOperands matches several series on time and returns, for each of
+// them, one DataUnit per row of the common timeline. The join semantics are
+// those of Combine, generalized to any number of operands: JoinExact keeps
+// the timestamps shared by all, JoinAsOf follows the first operand, and
+// JoinRegular regularizes all of them first.
+func alignOperands(series []*TimeSeries, join JoinMode) ([][]DataUnit, error) {
+	switch join.Kind {
+	case JoinRegular:
+		reg := make([]*TimeSeries, len(series))
+		for i, ts := range series {
+			r, err := ts.RegularizeWith(join.Regular)
+			if err != nil {
+				return nil, err
+			}
+			reg[i] = &r
+		}
+		return alignOperands(reg, JoinMode{Kind: JoinExact})
+	case JoinAsOf:
+		first := series[0]
+		if !first.strictlyIncreasing() {
+			return nil, ErrUnsorted
+		}
+		cols := [][]DataUnit{first.DataSeries}
+		for _, ts := range series[1:] {
+			m, err := AsOf(first, ts, join.Tolerance, AsOfBackward)
+			if err != nil {
+				return nil, err
+			}
+			cols = append(cols, m.DataSeries)
+		}
+		return cols, nil
+	case JoinExact:
+		tsc := NewTsContainer()
+		for i, ts := range series {
+			if !ts.strictlyIncreasing() {
+				return nil, ErrUnsorted
+			}
+			tsc.Ts[strconv.Itoa(i)] = ts
+		}
+		timeline := tsc.commonTimestamps(true)
+		cols := make([][]DataUnit, len(series))
+		for i, ts := range series {
+			// Every timeline instant is a timestamp of ts: copy them over.
+			k := 0
+			for _, d := range ts.DataSeries {
+				if k < len(timeline) && d.Chron.Equal(timeline[k]) {
+					cols[i] = append(cols[i], d)
+					k++
+				}
+			}
+		}
+		return cols, nil
+	}
+	return nil, ErrBounds
+}
+
+// shiftSeries returns a copy of ts with every timestamp moved by d.
+func shiftSeries(ts *TimeSeries, d time.Duration) TimeSeries {
+	out := TimeSeries{Name: ts.Name}
+	for _, du := range ts.DataSeries {
+		du.Chron = du.Chron.Add(d)
+		out.AddDataUnit(du)
+	}
+	return out
+}
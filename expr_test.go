@@ -0,0 +1,136 @@
+package timeseries
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+func exprFixture() TsContainer {
+	load, pv := meters()
+	pv.DataSeries[1].Chron = load.DataSeries[1].Chron
+	pv.DataSeries[1].Status = StOutlier
+	tsc := NewTsContainer()
+	tsc.Ts["load"] = &load
+	tsc.Ts["pv"] = &pv
+	return tsc
+}
+
+func TestParseExpr_Errors(t *testing.T) {
+	cases := []string{"net = load -", "a = (load", "a = load $ pv", "a = 3 pv", "a = `pv"}
+	for _, src := range cases {
+		_, err := ParseExpr(src)
+		var ee *ExprError
+		if !errors.As(err, &ee) {
+			t.Fatalf("%q: got %v, want an *ExprError", src, err)
+		}
+	}
+}
+
+func TestParseExpr_TargetAndRefs(t *testing.T) {
+	e, err := ParseExpr("cop = if(`elec meter` > 0.1, heat / `elec meter`, 0)")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if e.Target != "cop" {
+		t.Fatalf("target %q, want cop", e.Target)
+	}
+	refs := e.Refs()
+	if len(refs) != 2 || refs[0] != "elec meter" || refs[1] != "heat" {
+		t.Fatalf("refs %v, want [elec meter heat]", refs)
+	}
+}
+
+func TestEval_Arithmetic(t *testing.T) {
+	tsc := exprFixture()
+	net, err := tsc.Eval("net = load - pv * 0.5 + 2^2", EvalOpts{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if tsc.Ts["net"] != net {
+		t.Fatal("result must be stored in the container")
+	}
+	// Only 12:01 is shared: 12 - 5*0.5 + 4.
+	if len(net.DataSeries) != 1 || net.DataSeries[0].Meas != 13.5 {
+		t.Fatalf("got %+v, want one point of 13.5", net.DataSeries)
+	}
+	if net.Name != "net" || net.DataSeries[0].Status != StOutlier {
+		t.Fatalf("name %q status %v, want net/StOutlier", net.Name, net.DataSeries[0].Status)
+	}
+}
+
+func TestEval_ScalarBroadcastAndIf(t *testing.T) {
+	tsc := exprFixture()
+	got, err := tsc.Eval("hi = if(load >= 14 && !(load == 16), clip(load * 10, 0, 150), -1)", EvalOpts{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []float64{-1, -1, 140, -1}
+	for i, w := range want {
+		if got.DataSeries[i].Meas != w {
+			t.Fatalf("point %d: got %v, want %v", i, got.DataSeries[i].Meas, w)
+		}
+	}
+}
+
+func TestEval_AsOfJoin(t *testing.T) {
+	tsc := exprFixture()
+	got, err := tsc.Eval("m = max(load, pv) / abs(-pv)", EvalOpts{Join: JoinMode{Kind: JoinAsOf, Tolerance: NoTolerance}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got.DataSeries) != 4 || got.DataSeries[0].Status != StMissing || !math.IsNaN(got.DataSeries[0].Meas) {
+		t.Fatalf("first point must be missing, got %+v", got.DataSeries)
+	}
+	if got.DataSeries[1].Meas != 12.0/5 || got.DataSeries[3].Meas != 16.0/6 {
+		t.Fatalf("got %+v", got.DataSeries)
+	}
+}
+
+func TestEval_ShiftAndRollingMean(t *testing.T) {
+	tsc := exprFixture()
+	got, err := tsc.Eval("s = rolling_mean(shift(load, 1m), 2m)", EvalOpts{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	t1 := tsc.Ts["load"].DataSeries[1].Chron
+	if !got.DataSeries[0].Chron.Equal(t1) {
+		t.Fatalf("shift: first point at %v, want %v", got.DataSeries[0].Chron, t1)
+	}
+	want := []float64{10, 11, 13, 15}
+	for i, w := range want {
+		if got.DataSeries[i].Meas != w {
+			t.Fatalf("point %d: got %v, want %v", i, got.DataSeries[i].Meas, w)
+		}
+	}
+	if d, _ := parseDurationLit("1d12h"); d != 36*time.Hour {
+		t.Fatalf("1d12h parsed as %v", d)
+	}
+}
+
+func TestEval_BareReferenceIsCopied(t *testing.T) {
+	tsc := exprFixture()
+	y, err := tsc.Eval("y = load", EvalOpts{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := tsc.Ts["load"].DataSeries[0]
+	y.DataSeries[0].Meas, y.DataSeries[0].Status = -1, StInvalid
+	if got := tsc.Ts["load"].DataSeries[0]; got.Meas != want.Meas || got.Status != want.Status {
+		t.Fatalf("changing y altered load: %+v", got)
+	}
+}
+
+func TestEval_Errors(t *testing.T) {
+	tsc := exprFixture()
+	if _, err := tsc.Eval("x = load + gas", EvalOpts{}); !errors.Is(err, ErrUnknownSeries) {
+		t.Fatalf("got %v, want ErrUnknownSeries", err)
+	}
+	var ee *ExprError
+	for _, src := range []string{"load + 1", "x = 1 + 2", "x = load + 1h", "x = nope(load)", "x = abs(load, pv)"} {
+		if _, err := tsc.Eval(src, EvalOpts{}); !errors.As(err, &ee) {
+			t.Fatalf("%q: got %v, want an *ExprError", src, err)
+		}
+	}
+}
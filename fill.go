@@ -0,0 +1,126 @@
+package timeseries
+
+import (
+	"math"
+	"time"
+)
+
+// FillGaps replaces missing observations (Status=StMissing) in place
+// according to policy, tagging every filled point as StImputed. It returns
+// the number of points that were filled.
+//
+// Only valid observations (Status=StOK with a finite Meas) are used as
+// sources; outliers and invalid points are neither sources nor filled.
+// value is used by FillConstant and ignored otherwise.
+//
+// maxGap limits the length of the gaps that are filled. A gap is a run of
+// consecutive missing points; its length is measured between the valid
+// observations surrounding it (or, at the edges of the series, between the
+// only neighbor and the farthest missing point). Gaps longer than maxGap are
+// left untouched. A maxGap of 0 means no limit.
+//
+// Errors:
+//   - ErrBounds on an unknown policy or a negative maxGap.
+//   - ErrUnsorted if Chron is not strictly increasing.
+func (ts *TimeSeries) FillGaps(policy FillPolicy, value float64, maxGap time.Duration) (int, error) {
+	if policy < FillNone || policy > FillConstant || maxGap < 0 {
+		return 0, ErrBounds
+	}
+	if policy == FillNone || len(ts.DataSeries) == 0 {
+		return 0, nil
+	}
+	if !ts.strictlyIncreasing() {
+		return 0, ErrUnsorted
+	}
+
+	ds := ts.DataSeries
+	isSource := func(k int) bool {
+		return ds[k].Status == StOK && !math.IsNaN(ds[k].Meas)
+	}
+	filled := 0
+	prev := -1
+	for i := 0; i < len(ds); {
+		if ds[i].Status != StMissing {
+			if isSource(i) {
+				prev = i
+			}
+			i++
+			continue
+		}
+		// Gap [i, j) of missing points, framed by prev and next.
+		j := i
+		for j < len(ds) && ds[j].Status == StMissing {
+			j++
+		}
+		next := -1
+		for k := j; k < len(ds); k++ {
+			if isSource(k) {
+				next = k
+				break
+			}
+		}
+
+		var span time.Duration
+		switch {
+		case prev >= 0 && next >= 0:
+			span = ds[next].Chron.Sub(ds[prev].Chron)
+		case prev >= 0:
+			span = ds[j-1].Chron.Sub(ds[prev].Chron)
+		case next >= 0:
+			span = ds[next].Chron.Sub(ds[i].Chron)
+		}
+		if maxGap > 0 && span > maxGap {
+			i = j
+			continue
+		}
+
+		for k := i; k < j; k++ {
+			v, ok := fillValue(policy, ds, prev, next, k, value)
+			if !ok {
+				continue
+			}
+			ds[k].Meas = v
+			ds[k].Status = StImputed
+			filled++
+		}
+		i = j
+	}
+	return filled, nil
+}
+
+// fillValue computes the imputed value of ds[k] from its valid neighbors
+// ds[prev] and ds[next] (-1 when absent). ok is false when the policy cannot
+// produce a value with the available neighbors.
+func fillValue(policy FillPolicy, ds []DataUnit, prev, next, k int, value float64) (v float64, ok bool) {
+	switch policy {
+	case FillConstant:
+		return value, true
+	case FillPrevious:
+		if prev >= 0 {
+			return ds[prev].Meas, true
+		}
+	case FillNext:
+		if next >= 0 {
+			return ds[next].Meas, true
+		}
+	case FillLinear:
+		if prev >= 0 && next >= 0 {
+			w := float64(ds[k].Chron.Sub(ds[prev].Chron)) / float64(ds[next].Chron.Sub(ds[prev].Chron))
+			return ds[prev].Meas + w*(ds[next].Meas-ds[prev].Meas), true
+		}
+	case FillNearest:
+		switch {
+		case prev >= 0 && next >= 0:
+			// Ties go to the previous observation.
+			if ds[next].Chron.Sub(ds[k].Chron) < ds[k].Chron.Sub(ds[prev].Chron) {
+				return ds[next].Meas, true
+			}
+			return ds[prev].Meas, true
+		case prev >= 0:
+			return ds[prev].Meas, true
+		case next >= 0:
+			return ds[next].Meas, true
+		}
+	}
+	return math.NaN(), false
+}
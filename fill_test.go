@@ -0,0 +1,109 @@
+package timeseries
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+// gappySeries: one point per minute, values 0,1,2 then 3 missing then 6,7.
+func gappySeries() TimeSeries {
+	t0 := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	ts := TimeSeries{Name: "gappy"}
+	vals := []float64{0, 1, 2, math.NaN(), math.NaN(), math.NaN(), 6, 7}
+	for i, v := range vals {
+		st := StOK
+		if math.IsNaN(v) {
+			st = StMissing
+		}
+		ts.AddDataUnit(NewDataUnitWithStatus(t0.Add(time.Duration(i)*time.Minute), v, st))
+	}
+	return ts
+}
+
+func TestFillGaps_Policies(t *testing.T) {
+	cases := []struct {
+		name   string
+		policy FillPolicy
+		want   []float64
+	}{
+		{"previous", FillPrevious, []float64{2, 2, 2}},
+		{"next", FillNext, []float64{6, 6, 6}},
+		{"linear", FillLinear, []float64{3, 4, 5}},
+		{"nearest", FillNearest, []float64{2, 2, 6}},
+		{"constant", FillConstant, []float64{-1, -1, -1}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			ts := gappySeries()
+			n, err := ts.FillGaps(c.policy, -1, 0)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if n != 3 {
+				t.Fatalf("filled %d points, want 3", n)
+			}
+			for k, want := range c.want {
+				du := ts.DataSeries[3+k]
+				if !almostEq(du.Meas, want, 1e-12) || du.Status != StImputed {
+					t.Fatalf("point %d: got (%v,%v), want (%v,StImputed)", 3+k, du.Meas, du.Status, want)
+				}
+			}
+		})
+	}
+}
+
+func TestFillGaps_MaxGapAndEdges(t *testing.T) {
+	ts := gappySeries()
+	// The gap spans 2..6 minutes = 4 minutes between valid neighbors.
+	n, err := ts.FillGaps(FillLinear, 0, 3*time.Minute)
+	if err != nil || n != 0 {
+		t.Fatalf("maxGap too short: got (%d,%v), want (0,nil)", n, err)
+	}
+	if ts.DataSeries[3].Status != StMissing || !math.IsNaN(ts.DataSeries[3].Meas) {
+		t.Fatalf("gap above maxGap must stay missing")
+	}
+	if n, _ = ts.FillGaps(FillLinear, 0, 4*time.Minute); n != 3 {
+		t.Fatalf("maxGap equal to gap: filled %d, want 3", n)
+	}
+
+	// Leading gap: nothing to carry forward, NOCB fills it.
+	lead := gappySeries()
+	lead.DataSeries[0].Status, lead.DataSeries[0].Meas = StMissing, math.NaN()
+	if n, _ = lead.FillGaps(FillPrevious, 0, 0); n != 3 {
+		t.Fatalf("LOCF must skip leading gap: filled %d, want 3", n)
+	}
+	if n, _ = lead.FillGaps(FillNext, 0, 0); n != 1 || lead.DataSeries[0].Meas != 1 {
+		t.Fatalf("NOCB on leading gap: filled %d value %v", n, lead.DataSeries[0].Meas)
+	}
+}
+
+func TestFillGaps_Errors(t *testing.T) {
+	ts := gappySeries()
+	if _, err := ts.FillGaps(FillPolicy(99), 0, 0); err != ErrBounds {
+		t.Fatalf("unknown policy: got %v, want ErrBounds", err)
+	}
+	ts.DataSeries[1].Chron = ts.DataSeries[0].Chron
+	if _, err := ts.FillGaps(FillLinear, 0, 0); err != ErrUnsorted {
+		t.Fatalf("duplicate Chron: got %v, want ErrUnsorted", err)
+	}
+}
+
+func TestRegularizeWith_Fill(t *testing.T) {
+	base := mustTime(2025, 11, 10, 10, 0, 0)
+	ts := TimeSeries{}
+	ts.AddDataUnit(
+		du(base.Add(5*time.Second), 1),
+		du(base.Add(95*time.Second), 4),
+	)
+	got, err := ts.RegularizeWith(RegularizeOpts{Period: 30 * time.Second, Agg: AggLast, Fill: FillLinear})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := TimeSeries{}
+	want.AddDataUnit(du(base, 1), du(base.Add(30*time.Second), 2), du(base.Add(60*time.Second), 3), du(base.Add(90*time.Second), 4))
+	requireSeriesEq(t, got, want, 1e-12)
+	if got.DataSeries[1].Status != StImputed {
+		t.Fatalf("filled bucket status %v, want StImputed", got.DataSeries[1].Status)
+	}
+}
@@ -0,0 +1,243 @@
+package timeseries
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// Frame is a columnar, multivariate view of aligned series: one shared time
+// index and, for every named column, the values and status codes at each
+// index position. It is the natural input of correlation, regression or
+// tabular export, which need matrices rather than a map of series.
+//
+// Layout (c is the column, r the row):
+//   - Index[r]:     the timestamp of row r, strictly increasing.
+//   - Columns[c]:   the name of column c.
+//   - Data[c][r]:   the value of column c at row r (NaN when missing).
+//   - Status[c][r]: the StatusCode of that cell.
+//
+// Selections (Select, Rows, Between) return copies and leave the receiver
+// untouched; AddColumn and Derive add or replace columns in place.
+type Frame struct {
+	Name    string
+	Index   []time.Time
+	Columns []string
+	Data    [][]float64
+	Status  [][]StatusCode
+}
+
+// ToFrame builds a Frame from an aligned container (see Align): every
+// series must have exactly the same timestamps. Columns are the series
+// names in lexical order.
+//
+// Errors:
+//   - ErrSize if the series have different lengths.
+//   - ErrUnaligned if they differ on any timestamp.
+func (tsc *TsContainer) ToFrame() (Frame, error) {
+	f := Frame{Name: tsc.Name}
+	for name, ts := range tsc.Ts {
+		if ts != nil {
+			f.Columns = append(f.Columns, name)
+		}
+	}
+	sort.Strings(f.Columns)
+	if len(f.Columns) == 0 {
+		return f, nil
+	}
+
+	ref := tsc.Ts[f.Columns[0]].DataSeries
+	f.Index = make([]time.Time, len(ref))
+	for r, d := range ref {
+		f.Index[r] = d.Chron
+	}
+	for _, name := range f.Columns {
+		ds := tsc.Ts[name].DataSeries
+		if len(ds) != len(f.Index) {
+			return Frame{}, ErrSize
+		}
+		vals := make([]float64, len(ds))
+		sts := make([]StatusCode, len(ds))
+		for r, d := range ds {
+			if !d.Chron.Equal(f.Index[r]) {
+				return Frame{}, ErrUnaligned
+			}
+			vals[r], sts[r] = d.Meas, d.Status
+		}
+		f.Data = append(f.Data, vals)
+		f.Status = append(f.Status, sts)
+	}
+	return f, nil
+}
+
+// Len returns the number of rows of the frame.
+func (f *Frame) Len() int {
+	return len(f.Index)
+}
+
+// colIndex returns the position of the named column, or -1.
+func (f *Frame) colIndex(name string) int {
+	for c, n := range f.Columns {
+		if n == name {
+			return c
+		}
+	}
+	return -1
+}
+
+// Col returns the values of the named column. The slice is shared with the
+// frame. It returns ErrUnknownSeries if there is no such column.
+func (f *Frame) Col(name string) ([]float64, error) {
+	c := f.colIndex(name)
+	if c < 0 {
+		return nil, ErrUnknownSeries
+	}
+	return f.Data[c], nil
+}
+
+// Row returns a newly allocated slice with the values of row r, one per
+// column in Columns order.
+func (f *Frame) Row(r int) []float64 {
+	row := make([]float64, len(f.Columns))
+	for c := range f.Columns {
+		row[c] = f.Data[c][r]
+	}
+	return row
+}
+
+// Select returns a frame holding only the named columns, in the given
+// order. It returns ErrUnknownSeries if a name is not a column.
+func (f *Frame) Select(names ...string) (Frame, error) {
+	out := Frame{Name: f.Name, Index: append([]time.Time(nil), f.Index...)}
+	for _, name := range names {
+		c := f.colIndex(name)
+		if c < 0 {
+			return Frame{}, ErrUnknownSeries
+		}
+		out.Columns = append(out.Columns, name)
+		out.Data = append(out.Data, append([]float64(nil), f.Data[c]...))
+		out.Status = append(out.Status, append([]StatusCode(nil), f.Status[c]...))
+	}
+	return out, nil
+}
+
+// Rows returns the rows [from, to) of the frame. Bounds are clamped to the
+// frame length.
+func (f *Frame) Rows(from, to int) Frame {
+	if from < 0 {
+		from = 0
+	}
+	if from > f.Len() {
+		from = f.Len()
+	}
+	if to > f.Len() {
+		to = f.Len()
+	}
+	if to < from {
+		to = from
+	}
+	out := Frame{
+		Name:    f.Name,
+		Index:   append([]time.Time(nil), f.Index[from:to]...),
+		Columns: append([]string(nil), f.Columns...),
+	}
+	for c := range f.Columns {
+		out.Data = append(out.Data, append([]float64(nil), f.Data[c][from:to]...))
+		out.Status = append(out.Status, append([]StatusCode(nil), f.Status[c][from:to]...))
+	}
+	return out
+}
+
+// Between returns the rows whose timestamp lies in [from, to).
+func (f *Frame) Between(from, to time.Time) Frame {
+	lo := sort.Search(f.Len(), func(r int) bool { return !f.Index[r].Before(from) })
+	hi := sort.Search(f.Len(), func(r int) bool { return !f.Index[r].Before(to) })
+	return f.Rows(lo, hi)
+}
+
+// AddColumn appends a copy of a column. status may be nil, in which case
+// cells are StOK, or StMissing where the value is NaN. An existing column
+// with the same name is replaced.
+//
+// Errors:
+//   - ErrSize if vals (or a non-nil status) does not have Len() entries.
+func (f *Frame) AddColumn(name string, vals []float64, status []StatusCode) error {
+	if len(vals) != f.Len() || (status != nil && len(status) != f.Len()) {
+		return ErrSize
+	}
+	vals = append([]float64(nil), vals...)
+	if status == nil {
+		status = make([]StatusCode, len(vals))
+		for r, v := range vals {
+			if math.IsNaN(v) {
+				status[r] = StMissing
+			}
+		}
+	} else {
+		status = append([]StatusCode(nil), status...)
+	}
+	if c := f.colIndex(name); c >= 0 {
+		f.Data[c], f.Status[c] = vals, status
+		return nil
+	}
+	f.Columns = append(f.Columns, name)
+	f.Data = append(f.Data, vals)
+	f.Status = append(f.Status, status)
+	return nil
+}
+
+// Derive computes a new column row by row as fn applied to the values of
+// the cols columns, in order, e.g.
+//
+//	f.Derive("net", func(x ...float64) float64 { return x[0] - x[1] }, "load", "pv")
+//
+// Each cell gets the worst status of its inputs; a NaN result is StMissing.
+// It returns ErrUnknownSeries if an input column does not exist.
+func (f *Frame) Derive(name string, fn func(x ...float64) float64, cols ...string) error {
+	idx := make([]int, len(cols))
+	for i, col := range cols {
+		if idx[i] = f.colIndex(col); idx[i] < 0 {
+			return ErrUnknownSeries
+		}
+	}
+	vals := make([]float64, f.Len())
+	sts := make([]StatusCode, f.Len())
+	args := make([]float64, len(cols))
+	in := make([]StatusCode, len(cols))
+	for r := range vals {
+		for i, c := range idx {
+			args[i], in[i] = f.Data[c][r], f.Status[c][r]
+		}
+		vals[r] = fn(args...)
+		sts[r] = worstStatus(in...)
+		if math.IsNaN(vals[r]) {
+			sts[r] = worstStatus(sts[r], StMissing)
+		}
+	}
+	return f.AddColumn(name, vals, sts)
+}
+
+// ToTimeSeries converts one column back to a TimeSeries named after it.
+// It returns ErrUnknownSeries if there is no such column.
+func (f *Frame) ToTimeSeries(name string) (TimeSeries, error) {
+	c := f.colIndex(name)
+	if c < 0 {
+		return TimeSeries{}, ErrUnknownSeries
+	}
+	ts := TimeSeries{Name: name}
+	for r, t := range f.Index {
+		ts.AddDataUnit(NewDataUnitWithStatus(t, f.Data[c][r], f.Status[c][r]))
+	}
+	return ts, nil
+}
+
+// ToContainer converts every column back to a series of a new container.
+func (f *Frame) ToContainer() TsContainer {
+	tsc := NewTsContainer()
+	tsc.Name = f.Name
+	for _, name := range f.Columns {
+		ts, _ := f.ToTimeSeries(name)
+		tsc.Ts[name] = &ts
+	}
+	return tsc
+}
@@ -0,0 +1,116 @@
+package timeseries
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func frameFixture(t *testing.T) Frame {
+	t.Helper()
+	tsc := alignFixture()
+	aligned, err := tsc.Align(AlignOpts{Mode: AlignUnion, Method: InterpExact})
+	if err != nil {
+		t.Fatalf("Align: %v", err)
+	}
+	f, err := aligned.ToFrame()
+	if err != nil {
+		t.Fatalf("ToFrame: %v", err)
+	}
+	return f
+}
+
+func TestToFrame_Layout(t *testing.T) {
+	f := frameFixture(t)
+	if f.Name != "pair" || f.Len() != 4 {
+		t.Fatalf("frame %q with %d rows, want pair with 4", f.Name, f.Len())
+	}
+	if len(f.Columns) != 2 || f.Columns[0] != "a" || f.Columns[1] != "b" {
+		t.Fatalf("columns %v, want [a b]", f.Columns)
+	}
+	row := f.Row(2) // 10s
+	if row[0] != 10 || row[1] != 1 {
+		t.Fatalf("row at 10s = %v, want [10 1]", row)
+	}
+	if f.Status[1][0] != StMissing {
+		t.Fatalf("b at 0s should be StMissing")
+	}
+
+	tsc := alignFixture() // raw series: not aligned
+	if _, err := tsc.ToFrame(); err != ErrSize {
+		t.Fatalf("raw container: got %v, want ErrSize", err)
+	}
+	tsc.Ts["b"].DataSeries = append(tsc.Ts["b"].DataSeries, DataUnit{Chron: time.Unix(0, 0)})
+	if _, err := tsc.ToFrame(); err != ErrUnaligned {
+		t.Fatalf("same length, other timestamps: got %v, want ErrUnaligned", err)
+	}
+}
+
+func TestFrame_SelectionsAndSlicing(t *testing.T) {
+	f := frameFixture(t)
+	sel, err := f.Select("b")
+	if err != nil || len(sel.Columns) != 1 || sel.Columns[0] != "b" {
+		t.Fatalf("Select(b) = %v, %v", sel.Columns, err)
+	}
+	if _, err := f.Select("zz"); err != ErrUnknownSeries {
+		t.Fatalf("unknown column: got %v, want ErrUnknownSeries", err)
+	}
+	rows := f.Rows(1, 3)
+	if rows.Len() != 2 || !rows.Index[0].Equal(f.Index[1]) {
+		t.Fatalf("Rows(1,3) wrong: %v", rows.Index)
+	}
+	rows.Data[0][0] = -1
+	if f.Data[0][1] == -1 {
+		t.Fatalf("Rows must copy the data")
+	}
+	if out := f.Rows(f.Len()+3, f.Len()+8); out.Len() != 0 || len(out.Data) != len(f.Columns) {
+		t.Fatalf("Rows past the end must be empty, got %d rows", out.Len())
+	}
+	between := f.Between(f.Index[1], f.Index[3])
+	if between.Len() != 2 {
+		t.Fatalf("Between: got %d rows, want 2", between.Len())
+	}
+}
+
+func TestFrame_DeriveAndBack(t *testing.T) {
+	f := frameFixture(t)
+	err := f.Derive("diff", func(x ...float64) float64 { return x[0] - x[1] }, "a", "b")
+	if err != nil {
+		t.Fatalf("Derive: %v", err)
+	}
+	diff, err := f.ToTimeSeries("diff")
+	if err != nil {
+		t.Fatalf("ToTimeSeries: %v", err)
+	}
+	if d := diff.DataSeries[2]; d.Meas != 9 || d.Status != StOK {
+		t.Fatalf("diff at 10s = (%v,%v), want (9,StOK)", d.Meas, d.Status)
+	}
+	if d := diff.DataSeries[0]; !math.IsNaN(d.Meas) || d.Status != StMissing {
+		t.Fatalf("diff with a missing operand = (%v,%v), want (NaN,StMissing)", d.Meas, d.Status)
+	}
+	if err := f.Derive("x", func(x ...float64) float64 { return 0 }, "nope"); err != ErrUnknownSeries {
+		t.Fatalf("unknown input: got %v, want ErrUnknownSeries", err)
+	}
+	if err := f.AddColumn("short", []float64{1}, nil); err != ErrSize {
+		t.Fatalf("short column: got %v, want ErrSize", err)
+	}
+
+	tsc := f.ToContainer()
+	if len(tsc.Ts) != 3 || tsc.Ts["diff"] == nil {
+		t.Fatalf("ToContainer: got %d series", len(tsc.Ts))
+	}
+}
+
+func TestFrame_AddColumnCopies(t *testing.T) {
+	f := frameFixture(t)
+	vals := make([]float64, f.Len())
+	status := make([]StatusCode, f.Len())
+	if err := f.AddColumn("c", vals, status); err != nil {
+		t.Fatalf("AddColumn: %v", err)
+	}
+	vals[0], status[0] = 42, StInvalid
+	c, _ := f.Col("c")
+	if c[0] != 0 || f.Status[len(f.Columns)-1][0] != StOK {
+		t.Fatalf("AddColumn must copy its inputs, got %v / %v", c[0], f.Status[len(f.Columns)-1][0])
+	}
+}
@@ -0,0 +1,447 @@
+package timeseries
+
+import (
+	"encoding/binary"
+	"math"
+	"math/bits"
+	"time"
+)
+
+// Gorilla encoding packs a series into a compact byte stream, after
+// Pelkonen et al., "Gorilla: A Fast, Scalable, In-Memory Time Series
+// Database" (VLDB 2015):
+//   - timestamps are stored as delta-of-delta, so that a regular series
+//     costs one bit per point;
+//   - values are XOR-ed with the previous one and only the meaningful bits
+//     are stored, one bit per repeated value;
+//   - status codes are run-length encoded.
+//
+// The stream is cut into blocks of at most blockSize points, each with a
+// header giving its time range and byte length, so that a time range can
+// be decoded without touching the other blocks (see DecodeGorillaRange).
+//
+// Layout (varints as in encoding/binary):
+//
+//	stream: "TSG1" | uvarint len(name) | name | block...
+//	block:  uvarint count | varint first ns | varint last ns | uvarint len(payload) | payload
+//	payload: uvarint runs | (status byte, uvarint length)... | bits
+//
+// Only Chron, Meas and Status are stored; times come back in UTC and
+// deltas can be recomputed with DeltasFiller. Values are kept bit for bit.
+
+// DefaultGorillaBlock is the block size used by EncodeGorilla when 0 is
+// given.
+const DefaultGorillaBlock = 1024
+
+var gorillaMagic = []byte("TSG1")
+
+// GorillaBlock describes one block of an encoded stream.
+type GorillaBlock struct {
+	Offset int       // offset of the block header in the stream
+	Count  int       // number of points
+	First  time.Time // earliest timestamp of the block
+	Last   time.Time // latest timestamp of the block
+}
+
+// EncodeGorilla packs the series into a Gorilla byte stream with blocks of
+// blockSize points (DefaultGorillaBlock if 0). Timestamps need not be
+// sorted, but sorted series compress best and make range decoding
+// selective. It returns ErrBounds for a negative blockSize.
+func (ts *TimeSeries) EncodeGorilla(blockSize int) ([]byte, error) {
+	if blockSize < 0 {
+		return nil, ErrBounds
+	}
+	if blockSize == 0 {
+		blockSize = DefaultGorillaBlock
+	}
+	out := append([]byte(nil), gorillaMagic...)
+	out = binary.AppendUvarint(out, uint64(len(ts.Name)))
+	out = append(out, ts.Name...)
+
+	for lo := 0; lo < len(ts.DataSeries); lo += blockSize {
+		hi := min(lo+blockSize, len(ts.DataSeries))
+		out = appendGorillaBlock(out, ts.DataSeries[lo:hi])
+	}
+	return out, nil
+}
+
+// appendGorillaBlock encodes one non-empty block.
+func appendGorillaBlock(out []byte, ds []DataUnit) []byte {
+	first, last := ds[0].Chron.UnixNano(), ds[0].Chron.UnixNano()
+	for _, d := range ds[1:] {
+		first, last = min(first, d.Chron.UnixNano()), max(last, d.Chron.UnixNano())
+	}
+
+	var payload []byte
+	var runs [][2]uint64
+	for _, d := range ds {
+		if n := len(runs); n > 0 && runs[n-1][0] == uint64(d.Status) {
+			runs[n-1][1]++
+		} else {
+			runs = append(runs, [2]uint64{uint64(d.Status), 1})
+		}
+	}
+	payload = binary.AppendUvarint(payload, uint64(len(runs)))
+	for _, r := range runs {
+		payload = append(payload, byte(r[0]))
+		payload = binary.AppendUvarint(payload, r[1])
+	}
+
+	w := bitWriter{buf: payload}
+	var tEnc timeEncoder
+	var vEnc xorEncoder
+	for i, d := range ds {
+		tEnc.write(&w, d.Chron.UnixNano(), i == 0)
+		vEnc.write(&w, math.Float64bits(d.Meas), i == 0)
+	}
+
+	out = binary.AppendUvarint(out, uint64(len(ds)))
+	out = binary.AppendVarint(out, first)
+	out = binary.AppendVarint(out, last)
+	out = binary.AppendUvarint(out, uint64(len(w.buf)))
+	return append(out, w.buf...)
+}
+
+// DecodeGorilla unpacks a stream written by EncodeGorilla. It returns
+// ErrCorrupt if the stream is malformed.
+func DecodeGorilla(b []byte) (TimeSeries, error) {
+	return decodeGorilla(b, func(GorillaBlock) bool { return true }, nil)
+}
+
+// DecodeGorillaRange unpacks only the points whose timestamp lies in
+// [from, to), skipping the blocks that cannot hold any.
+func DecodeGorillaRange(b []byte, from, to time.Time) (TimeSeries, error) {
+	overlaps := func(blk GorillaBlock) bool {
+		return blk.Last.Compare(from) >= 0 && blk.First.Before(to)
+	}
+	keep := func(d DataUnit) bool {
+		return !d.Chron.Before(from) && d.Chron.Before(to)
+	}
+	return decodeGorilla(b, overlaps, keep)
+}
+
+// GorillaBlocks lists the blocks of a stream from their headers only.
+func GorillaBlocks(b []byte) ([]GorillaBlock, error) {
+	var blocks []GorillaBlock
+	_, err := walkGorilla(b, func(blk GorillaBlock, _ []byte) error {
+		blocks = append(blocks, blk)
+		return nil
+	})
+	return blocks, err
+}
+
+// decodeGorilla decodes the blocks accepted by want, keeping the points
+// accepted by keep (all if nil).
+func decodeGorilla(b []byte, want func(GorillaBlock) bool, keep func(DataUnit) bool) (TimeSeries, error) {
+	var ts TimeSeries
+	name, err := walkGorilla(b, func(blk GorillaBlock, payload []byte) error {
+		if !want(blk) {
+			return nil
+		}
+		ds, err := decodeGorillaBlock(payload, blk.Count)
+		if err != nil {
+			return err
+		}
+		for _, d := range ds {
+			if keep == nil || keep(d) {
+				ts.DataSeries = append(ts.DataSeries, d)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return TimeSeries{}, err
+	}
+	ts.Name = name
+	return ts, nil
+}
+
+// walkGorilla checks the stream header, then calls fn on every block with
+// its payload. It returns the series name.
+func walkGorilla(b []byte, fn func(GorillaBlock, []byte) error) (string, error) {
+	if len(b) < len(gorillaMagic) || string(b[:len(gorillaMagic)]) != string(gorillaMagic) {
+		return "", ErrCorrupt
+	}
+	r := byteReader{buf: b, pos: len(gorillaMagic)}
+	nameLen := r.uvarint()
+	name := string(r.bytes(nameLen))
+	for r.err == nil && r.pos < len(b) {
+		blk := GorillaBlock{Offset: r.pos}
+		blk.Count = int(r.uvarint())
+		blk.First = time.Unix(0, r.varint()).UTC()
+		blk.Last = time.Unix(0, r.varint()).UTC()
+		payload := r.bytes(r.uvarint())
+		// Every point after the first takes at least two bits.
+		if r.err != nil || blk.Count <= 0 || blk.Count > 4*len(payload)+1 {
+			return "", ErrCorrupt
+		}
+		if err := fn(blk, payload); err != nil {
+			return "", err
+		}
+	}
+	return name, r.err
+}
+
+// decodeGorillaBlock decodes the count points of a block payload.
+func decodeGorillaBlock(payload []byte, count int) ([]DataUnit, error) {
+	r := byteReader{buf: payload}
+	ds := make([]DataUnit, count)
+	nRuns := r.uvarint()
+	i := 0
+	for k := uint64(0); k < nRuns && r.err == nil; k++ {
+		st := StatusCode(r.readByte())
+		n := r.uvarint()
+		if n > uint64(count-i) {
+			return nil, ErrCorrupt
+		}
+		for ; n > 0; n-- {
+			ds[i].Status = st
+			i++
+		}
+	}
+	if r.err != nil || i != count {
+		return nil, ErrCorrupt
+	}
+
+	br := bitReader{buf: payload[r.pos:]}
+	var tDec timeDecoder
+	var vDec xorDecoder
+	for i := range ds {
+		t := tDec.read(&br, i == 0)
+		v := vDec.read(&br, i == 0)
+		if br.err != nil {
+			return nil, ErrCorrupt
+		}
+		ds[i].Chron = time.Unix(0, t).UTC()
+		ds[i].Meas = math.Float64frombits(v)
+	}
+	return ds, nil
+}
+
+// dodBuckets are the delta-of-delta classes after the '0' of a zero
+// delta-of-delta: a prefix of plen bits, then a signed value of n bits.
+// The widths suit nanosecond timestamps with up to millisecond jitter.
+var dodBuckets = []struct {
+	prefix uint64
+	plen   int
+	n      int
+}{
+	{0b10, 2, 14},
+	{0b110, 3, 24},
+	{0b1110, 4, 36},
+	{0b1111, 4, 64},
+}
+
+// timeEncoder writes timestamps as delta-of-delta.
+type timeEncoder struct {
+	prev, delta int64
+}
+
+func (e *timeEncoder) write(w *bitWriter, t int64, first bool) {
+	if first {
+		w.writeBits(uint64(t), 64)
+		e.prev, e.delta = t, 0
+		return
+	}
+	delta := t - e.prev
+	dod := delta - e.delta
+	e.prev, e.delta = t, delta
+	if dod == 0 {
+		w.writeBits(0, 1)
+		return
+	}
+	for _, b := range dodBuckets {
+		if b.n == 64 || (dod >= -1<<(b.n-1) && dod < 1<<(b.n-1)) {
+			w.writeBits(b.prefix, b.plen)
+			w.writeBits(uint64(dod), b.n)
+			return
+		}
+	}
+}
+
+// timeDecoder reads timestamps written by timeEncoder.
+type timeDecoder struct {
+	prev, delta int64
+}
+
+func (d *timeDecoder) read(r *bitReader, first bool) int64 {
+	if first {
+		d.prev, d.delta = int64(r.readBits(64)), 0
+		return d.prev
+	}
+	var dod int64
+	if r.readBits(1) == 1 {
+		k := 0
+		for k < len(dodBuckets)-1 && r.readBits(1) == 1 {
+			k++
+		}
+		n := dodBuckets[k].n
+		dod = int64(r.readBits(n)<<(64-n)) >> (64 - n) // sign extension
+	}
+	d.delta += dod
+	d.prev += d.delta
+	return d.prev
+}
+
+// xorEncoder writes float64 bit patterns XOR-ed with the previous one.
+type xorEncoder struct {
+	prev        uint64
+	lead, trail int
+	window      bool
+}
+
+func (e *xorEncoder) write(w *bitWriter, v uint64, first bool) {
+	if first {
+		w.writeBits(v, 64)
+		e.prev = v
+		return
+	}
+	x := v ^ e.prev
+	e.prev = v
+	if x == 0 {
+		w.writeBits(0, 1)
+		return
+	}
+	lead, trail := min(bits.LeadingZeros64(x), 31), bits.TrailingZeros64(x)
+	if e.window && lead >= e.lead && trail >= e.trail {
+		// The meaningful bits fit in the previous window.
+		w.writeBits(0b10, 2)
+		w.writeBits(x>>e.trail, 64-e.lead-e.trail)
+		return
+	}
+	sig := 64 - lead - trail
+	w.writeBits(0b11, 2)
+	w.writeBits(uint64(lead), 5)
+	w.writeBits(uint64(sig&63), 6) // 64 is written as 0
+	w.writeBits(x>>trail, sig)
+	e.lead, e.trail, e.window = lead, trail, true
+}
+
+// xorDecoder reads values written by xorEncoder.
+type xorDecoder struct {
+	prev        uint64
+	lead, trail int
+}
+
+func (d *xorDecoder) read(r *bitReader, first bool) uint64 {
+	if first {
+		d.prev = r.readBits(64)
+		return d.prev
+	}
+	if r.readBits(1) == 0 {
+		return d.prev
+	}
+	if r.readBits(1) == 1 {
+		d.lead = int(r.readBits(5))
+		sig := int(r.readBits(6))
+		if sig == 0 {
+			sig = 64
+		}
+		d.trail = 64 - d.lead - sig
+		if d.trail < 0 {
+			r.err = ErrCorrupt
+			return 0
+		}
+	}
+	d.prev ^= r.readBits(64-d.lead-d.trail) << d.trail
+	return d.prev
+}
+
+// bitWriter appends bits, most significant first, to a byte slice.
+type bitWriter struct {
+	buf  []byte
+	free int // unused low bits of the last byte
+}
+
+// writeBits writes the n low bits of v.
+func (w *bitWriter) writeBits(v uint64, n int) {
+	for n > 0 {
+		if w.free == 0 {
+			w.buf = append(w.buf, 0)
+			w.free = 8
+		}
+		k := min(n, w.free)
+		chunk := byte(v>>(n-k)) & byte(1<<k-1)
+		w.buf[len(w.buf)-1] |= chunk << (w.free - k)
+		w.free -= k
+		n -= k
+	}
+}
+
+// bitReader reads bits written by bitWriter. Reading past the end sets
+// err and returns zeros.
+type bitReader struct {
+	buf []byte
+	pos int // in bits
+	err error
+}
+
+// readBits reads n bits into the low bits of the result.
+func (r *bitReader) readBits(n int) uint64 {
+	if r.err != nil || r.pos+n > 8*len(r.buf) {
+		r.err = ErrCorrupt
+		return 0
+	}
+	var v uint64
+	for n > 0 {
+		avail := 8 - r.pos&7
+		k := min(n, avail)
+		chunk := r.buf[r.pos>>3] >> (avail - k) & byte(1<<k-1)
+		v = v<<k | uint64(chunk)
+		r.pos += k
+		n -= k
+	}
+	return v
+}
+
+// byteReader reads varints and byte strings. Reading past the end sets
+// err to ErrCorrupt and returns zeros.
+type byteReader struct {
+	buf []byte
+	pos int
+	err error
+}
+
+func (r *byteReader) uvarint() uint64 {
+	if r.err != nil {
+		return 0
+	}
+	v, n := binary.Uvarint(r.buf[r.pos:])
+	if n <= 0 {
+		r.err = ErrCorrupt
+		return 0
+	}
+	r.pos += n
+	return v
+}
+
+func (r *byteReader) varint() int64 {
+	if r.err != nil {
+		return 0
+	}
+	v, n := binary.Varint(r.buf[r.pos:])
+	if n <= 0 {
+		r.err = ErrCorrupt
+		return 0
+	}
+	r.pos += n
+	return v
+}
+
+func (r *byteReader) readByte() byte {
+	if r.err != nil || r.pos >= len(r.buf) {
+		r.err = ErrCorrupt
+		return 0
+	}
+	r.pos++
+	return r.buf[r.pos-1]
+}
+
+func (r *byteReader) bytes(n uint64) []byte {
+	if r.err != nil || n > uint64(len(r.buf)-r.pos) {
+		r.err = ErrCorrupt
+		return nil
+	}
+	r.pos += int(n)
+	return r.buf[r.pos-int(n) : r.pos]
+}
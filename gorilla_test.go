@@ -0,0 +1,99 @@
+package timeseries
+
+import (
+	"math"
+	"math/rand"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestGorilla_RoundTripIrregular(t *testing.T) {
+	rng := rand.New(rand.NewSource(7))
+	t0 := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	ts := TimeSeries{Name: "probe"}
+	at := t0
+	for i := 0; i < 2500; i++ {
+		at = at.Add(time.Duration(rng.Intn(5000)) * time.Millisecond)
+		d := NewDataUnit(at, rng.NormFloat64()*100)
+		switch {
+		case i%97 == 0:
+			d.Meas, d.Status = math.NaN(), StMissing
+		case i%31 == 0:
+			d.Status = StOutlier
+		}
+		ts.AddDataUnit(d)
+	}
+	// An out-of-order point and extreme values must survive too.
+	ts.DataSeries[10].Chron = t0.Add(-time.Hour)
+	ts.DataSeries[11].Meas = math.Inf(-1)
+	ts.DataSeries[12].Meas = math.SmallestNonzeroFloat64
+
+	b, err := ts.EncodeGorilla(300)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	back, err := DecodeGorilla(b)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if back.Name != "probe" || len(back.DataSeries) != len(ts.DataSeries) {
+		t.Fatalf("got %q with %d points", back.Name, len(back.DataSeries))
+	}
+	for i, d := range back.DataSeries {
+		o := ts.DataSeries[i]
+		if !d.Chron.Equal(o.Chron) || d.Status != o.Status || math.Float64bits(d.Meas) != math.Float64bits(o.Meas) {
+			t.Fatalf("point %d: got %+v, want %+v", i, d, o)
+		}
+	}
+}
+
+func TestGorilla_CompressesRegularData(t *testing.T) {
+	t0 := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	ts := TimeSeries{}
+	for i := 0; i < 86400; i++ {
+		// A slowly varying sensor reading with 0.5 resolution.
+		v := math.Round(40+10*math.Sin(float64(i)/3600)) / 2
+		ts.AddDataUnit(NewDataUnit(t0.Add(time.Duration(i)*time.Second), v))
+	}
+	b, err := ts.EncodeGorilla(0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if perPoint := float64(len(b)) / float64(len(ts.DataSeries)); perPoint > 1 {
+		t.Fatalf("%.2f bytes per point, want at most 1", perPoint)
+	}
+}
+
+func TestGorilla_RangeAndBlocks(t *testing.T) {
+	ts := mkTS(0, 1, 2, 3, 4, 5, 6, 7, 8, 9)
+	b, _ := ts.EncodeGorilla(4)
+	blocks, err := GorillaBlocks(b)
+	if err != nil || len(blocks) != 3 || blocks[2].Count != 2 {
+		t.Fatalf("unexpected blocks %+v, %v", blocks, err)
+	}
+	if !blocks[1].First.Equal(ts.DataSeries[4].Chron) || !blocks[1].Last.Equal(ts.DataSeries[7].Chron) {
+		t.Fatalf("unexpected block range %+v", blocks[1])
+	}
+
+	got, err := DecodeGorillaRange(b, ts.DataSeries[3].Chron, ts.DataSeries[6].Chron)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(measSlice(got), []float64{3, 4, 5}) {
+		t.Fatalf("got %v", measSlice(got))
+	}
+}
+
+func TestGorilla_Corrupt(t *testing.T) {
+	ts := mkTS(1, 2, 3)
+	b, _ := ts.EncodeGorilla(0)
+	for _, bad := range [][]byte{nil, []byte("XXXX"), b[:len(b)-1]} {
+		if _, err := DecodeGorilla(bad); err != ErrCorrupt {
+			t.Fatalf("expected ErrCorrupt for %v, got %v", bad, err)
+		}
+	}
+	if _, err := ts.EncodeGorilla(-1); err != ErrBounds {
+		t.Fatalf("expected ErrBounds, got %v", err)
+	}
+}
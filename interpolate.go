@@ -0,0 +1,244 @@
+package timeseries
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// Resample evaluates the series at every timestamp of grid, interpolating
+// between observations with method and extrapolating outside of them with
+// extrap. It is the upsampling counterpart of RegularizeWith: nothing is
+// aggregated, each grid point gets a value derived from its neighbors.
+//
+// Knots are the observations with a finite Meas whose Status is neither
+// StMissing nor StInvalid. Status propagates from the knots:
+//   - a grid point equal to a knot timestamp takes that knot's value and
+//     status;
+//   - an interpolated point takes the worst status of its two neighboring
+//     knots (the chosen knot for InterpStep and InterpNearest);
+//   - an extrapolated point is StImputed, or StMissing with ExtrapNone;
+//   - with InterpExact, any point that is not a knot timestamp is StMissing.
+//
+// The output has one point per grid timestamp, in grid order.
+//
+// Errors:
+//   - ErrBounds on an unknown method or extrapolation policy.
+//   - ErrUnsorted if the receiver's Chron is not strictly increasing.
+func (ts *TimeSeries) Resample(grid []time.Time, method Interp, extrap Extrapolation) (TimeSeries, error) {
+	out := TimeSeries{Name: ts.Name}
+	if method < InterpStep || method > InterpExact || extrap < ExtrapNone || extrap > ExtrapLinear {
+		return out, ErrBounds
+	}
+	if !ts.strictlyIncreasing() {
+		return out, ErrUnsorted
+	}
+
+	var knots []DataUnit
+	for _, d := range ts.DataSeries {
+		if isKnot(d) {
+			knots = append(knots, d)
+		}
+	}
+	n := len(knots)
+	if n == 0 {
+		for _, t := range grid {
+			out.AddDataUnit(DataUnit{Chron: t, Meas: math.NaN(), Status: StMissing})
+		}
+		return out, nil
+	}
+
+	// Abscissae in seconds from the first knot keep full precision.
+	t0 := knots[0].Chron
+	xs := make([]float64, n)
+	ys := make([]float64, n)
+	for k, d := range knots {
+		xs[k] = d.Chron.Sub(t0).Seconds()
+		ys[k] = d.Meas
+	}
+	curve := newCurve(method, xs, ys)
+
+	for _, t := range grid {
+		du := DataUnit{Chron: t}
+		x := t.Sub(t0).Seconds()
+		// k is the first knot at or after t.
+		k := sort.Search(n, func(i int) bool { return !knots[i].Chron.Before(t) })
+		switch {
+		case k < n && knots[k].Chron.Equal(t):
+			du.Meas, du.Status = knots[k].Meas, knots[k].Status
+		case method == InterpExact:
+			du.Meas, du.Status = math.NaN(), StMissing
+		case k == 0 || k == n:
+			du.Meas, du.Status = extrapolate(extrap, xs, ys, x, k == 0)
+		default:
+			a, b := knots[k-1], knots[k]
+			switch method {
+			case InterpStep:
+				du.Meas, du.Status = a.Meas, a.Status
+			case InterpNearest:
+				// Ties go to the previous observation.
+				if b.Chron.Sub(t) < t.Sub(a.Chron) {
+					du.Meas, du.Status = b.Meas, b.Status
+				} else {
+					du.Meas, du.Status = a.Meas, a.Status
+				}
+			default:
+				du.Meas = curve(k-1, x)
+				du.Status = worstStatus(a.Status, b.Status)
+			}
+		}
+		out.AddDataUnit(du)
+	}
+	return out, nil
+}
+
+// isKnot reports whether an observation carries a usable value for
+// interpolation: a finite Meas whose Status is neither StMissing nor
+// StInvalid.
+func isKnot(d DataUnit) bool {
+	return d.Status != StMissing && d.Status != StInvalid && !math.IsNaN(d.Meas) && !math.IsInf(d.Meas, 0)
+}
+
+// extrapolate evaluates a point before the first knot (before=true) or after
+// the last one.
+func extrapolate(extrap Extrapolation, xs, ys []float64, x float64, before bool) (float64, StatusCode) {
+	n := len(xs)
+	end, inner := n-1, n-2
+	if before {
+		end, inner = 0, 1
+	}
+	switch extrap {
+	case ExtrapHold:
+		return ys[end], StImputed
+	case ExtrapLinear:
+		if n < 2 {
+			return ys[end], StImputed
+		}
+		slope := (ys[end] - ys[inner]) / (xs[end] - xs[inner])
+		return ys[end] + slope*(x-xs[end]), StImputed
+	}
+	return math.NaN(), StMissing
+}
+
+// newCurve prepares the interpolant of method through (xs, ys) and returns
+// a function evaluating it at x inside segment k, i.e. xs[k] < x < xs[k+1].
+func newCurve(method Interp, xs, ys []float64) func(k int, x float64) float64 {
+	n := len(xs)
+	linear := func(k int, x float64) float64 {
+		w := (x - xs[k]) / (xs[k+1] - xs[k])
+		return ys[k] + w*(ys[k+1]-ys[k])
+	}
+	if n < 3 {
+		return linear
+	}
+	switch method {
+	case InterpCubic:
+		m := naturalSplineMoments(xs, ys)
+		return func(k int, x float64) float64 {
+			h := xs[k+1] - xs[k]
+			a, b := (xs[k+1]-x)/h, (x-xs[k])/h
+			return a*ys[k] + b*ys[k+1] + ((a*a*a-a)*m[k]+(b*b*b-b)*m[k+1])*h*h/6
+		}
+	case InterpPCHIP:
+		return hermite(xs, ys, pchipSlopes(xs, ys))
+	case InterpAkima:
+		return hermite(xs, ys, akimaSlopes(xs, ys))
+	}
+	return linear
+}
+
+// hermite returns the cubic Hermite interpolant with knot slopes ds.
+func hermite(xs, ys, ds []float64) func(k int, x float64) float64 {
+	return func(k int, x float64) float64 {
+		h := xs[k+1] - xs[k]
+		s := (x - xs[k]) / h
+		s2, s3 := s*s, s*s*s
+		return (2*s3-3*s2+1)*ys[k] + (s3-2*s2+s)*h*ds[k] + (-2*s3+3*s2)*ys[k+1] + (s3-s2)*h*ds[k+1]
+	}
+}
+
+// naturalSplineMoments returns the second derivatives of the natural cubic
+// spline through (xs, ys), solving the tridiagonal system with the Thomas
+// algorithm. len(xs) must be >= 3.
+func naturalSplineMoments(xs, ys []float64) []float64 {
+	n := len(xs)
+	m := make([]float64, n)
+	c := make([]float64, n) // modified super-diagonal
+	d := make([]float64, n) // modified right-hand side
+	for i := 1; i < n-1; i++ {
+		h0, h1 := xs[i]-xs[i-1], xs[i+1]-xs[i]
+		rhs := 6 * ((ys[i+1]-ys[i])/h1 - (ys[i]-ys[i-1])/h0)
+		diag := 2 * (h0 + h1)
+		if i > 1 {
+			diag -= h0 * c[i-1]
+			rhs -= h0 * d[i-1]
+		}
+		c[i] = h1 / diag
+		d[i] = rhs / diag
+	}
+	for i := n - 2; i >= 1; i-- {
+		m[i] = d[i] - c[i]*m[i+1]
+	}
+	return m
+}
+
+// pchipSlopes returns the Fritsch-Carlson knot slopes used by PCHIP, with
+// the shape-preserving three-point end conditions. len(xs) must be >= 3.
+func pchipSlopes(xs, ys []float64) []float64 {
+	n := len(xs)
+	h := make([]float64, n-1)
+	delta := make([]float64, n-1)
+	for k := 0; k < n-1; k++ {
+		h[k] = xs[k+1] - xs[k]
+		delta[k] = (ys[k+1] - ys[k]) / h[k]
+	}
+	ds := make([]float64, n)
+	for k := 1; k < n-1; k++ {
+		if delta[k-1]*delta[k] <= 0 {
+			continue
+		}
+		w1, w2 := 2*h[k]+h[k-1], h[k]+2*h[k-1]
+		ds[k] = (w1 + w2) / (w1/delta[k-1] + w2/delta[k])
+	}
+	ds[0] = pchipEnd(h[0], h[1], delta[0], delta[1])
+	ds[n-1] = pchipEnd(h[n-2], h[n-3], delta[n-2], delta[n-3])
+	return ds
+}
+
+// pchipEnd is the non-centered three-point end slope of PCHIP.
+func pchipEnd(h0, h1, d0, d1 float64) float64 {
+	d := ((2*h0+h1)*d0 - h0*d1) / (h0 + h1)
+	switch {
+	case math.Signbit(d) != math.Signbit(d0) || d0 == 0:
+		return 0
+	case math.Signbit(d0) != math.Signbit(d1) && math.Abs(d) > math.Abs(3*d0):
+		return 3 * d0
+	}
+	return d
+}
+
+// akimaSlopes returns the knot slopes of the Akima spline, extending the
+// segment slopes by two on each side. len(xs) must be >= 3.
+func akimaSlopes(xs, ys []float64) []float64 {
+	n := len(xs)
+	// m[k+2] is the slope of segment k; m[0], m[1], m[n+1], m[n+2] are the
+	// extrapolated slopes.
+	m := make([]float64, n+3)
+	for k := 0; k < n-1; k++ {
+		m[k+2] = (ys[k+1] - ys[k]) / (xs[k+1] - xs[k])
+	}
+	m[1] = 2*m[2] - m[3]
+	m[0] = 2*m[1] - m[2]
+	m[n+1] = 2*m[n] - m[n-1]
+	m[n+2] = 2*m[n+1] - m[n]
+	ds := make([]float64, n)
+	for k := 0; k < n; k++ {
+		w1, w2 := math.Abs(m[k+3]-m[k+2]), math.Abs(m[k+1]-m[k])
+		if w1+w2 == 0 {
+			ds[k] = (m[k+1] + m[k+2]) / 2
+			continue
+		}
+		ds[k] = (w1*m[k+1] + w2*m[k+2]) / (w1 + w2)
+	}
+	return ds
+}
@@ -0,0 +1,138 @@
+package timeseries
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+// quarterHourly returns points every 15 minutes with the given values.
+func quarterHourly(vals ...float64) TimeSeries {
+	t0 := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	ts := TimeSeries{Name: "meter"}
+	for i, v := range vals {
+		ts.AddData(t0.Add(time.Duration(i)*15*time.Minute), v)
+	}
+	return ts
+}
+
+func minuteGrid(from time.Time, n int) []time.Time {
+	grid := make([]time.Time, n)
+	for i := range grid {
+		grid[i] = from.Add(time.Duration(i) * time.Minute)
+	}
+	return grid
+}
+
+func TestResample_StepLinearNearest(t *testing.T) {
+	ts := quarterHourly(0, 15, 30)
+	t0 := ts.DataSeries[0].Chron
+	grid := []time.Time{t0, t0.Add(5 * time.Minute), t0.Add(10 * time.Minute), t0.Add(20 * time.Minute)}
+
+	cases := []struct {
+		method Interp
+		want   []float64
+	}{
+		{InterpStep, []float64{0, 0, 0, 15}},
+		{InterpLinear, []float64{0, 5, 10, 20}},
+		{InterpNearest, []float64{0, 0, 15, 15}},
+	}
+	for _, c := range cases {
+		got, err := ts.Resample(grid, c.method, ExtrapNone)
+		if err != nil {
+			t.Fatalf("method %d: unexpected error: %v", c.method, err)
+		}
+		for i, want := range c.want {
+			if !almostEq(got.DataSeries[i].Meas, want, 1e-9) {
+				t.Fatalf("method %d point %d: got %v, want %v", c.method, i, got.DataSeries[i].Meas, want)
+			}
+		}
+	}
+}
+
+func TestResample_CubicFamiliesReproduceLines(t *testing.T) {
+	// A straight line must be reproduced exactly by every cubic method.
+	ts := quarterHourly(1, 2, 3, 4, 5, 6)
+	grid := minuteGrid(ts.DataSeries[0].Chron, 76)
+	for _, m := range []Interp{InterpCubic, InterpPCHIP, InterpAkima} {
+		got, err := ts.Resample(grid, m, ExtrapNone)
+		if err != nil {
+			t.Fatalf("method %d: unexpected error: %v", m, err)
+		}
+		for i, d := range got.DataSeries {
+			if want := 1 + float64(i)/15; !almostEq(d.Meas, want, 1e-9) {
+				t.Fatalf("method %d minute %d: got %v, want %v", m, i, d.Meas, want)
+			}
+		}
+	}
+}
+
+func TestResample_PCHIPPreservesShape(t *testing.T) {
+	ts := quarterHourly(0, 0, 1, 1, 1)
+	grid := minuteGrid(ts.DataSeries[0].Chron, 61)
+	got, err := ts.Resample(grid, InterpPCHIP, ExtrapNone)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	prev := math.Inf(-1)
+	for i, d := range got.DataSeries {
+		if d.Meas < 0 || d.Meas > 1 || d.Meas < prev {
+			t.Fatalf("minute %d: %v breaks monotonicity or overshoots", i, d.Meas)
+		}
+		prev = d.Meas
+	}
+	// The natural spline, by contrast, overshoots on the same data.
+	cub, _ := ts.Resample(grid, InterpCubic, ExtrapNone)
+	overshoot := false
+	for _, d := range cub.DataSeries {
+		if d.Meas > 1 || d.Meas < 0 {
+			overshoot = true
+		}
+	}
+	if !overshoot {
+		t.Fatalf("expected the natural cubic spline to overshoot on a step")
+	}
+}
+
+func TestResample_ExtrapolationAndStatus(t *testing.T) {
+	ts := quarterHourly(0, 15, 30, 45)
+	ts.DataSeries[1].Status = StOutlier
+	ts.DataSeries[2] = NewDataUnitWithStatus(ts.DataSeries[2].Chron, math.NaN(), StMissing)
+	t0 := ts.DataSeries[0].Chron
+	grid := []time.Time{t0.Add(-15 * time.Minute), t0.Add(5 * time.Minute), t0.Add(30 * time.Minute), t0.Add(60 * time.Minute)}
+
+	got, err := ts.Resample(grid, InterpLinear, ExtrapNone)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if d := got.DataSeries[0]; !math.IsNaN(d.Meas) || d.Status != StMissing {
+		t.Fatalf("ExtrapNone: got (%v,%v)", d.Meas, d.Status)
+	}
+	if d := got.DataSeries[1]; d.Status != StOutlier {
+		t.Fatalf("neighbor outlier must propagate, got %v", d.Status)
+	}
+	// The missing knot at 30min is skipped: 15 -> 45 between 15 and 45 min.
+	if d := got.DataSeries[2]; !almostEq(d.Meas, 30, 1e-9) {
+		t.Fatalf("across missing knot: got %v, want 30", d.Meas)
+	}
+
+	got, _ = ts.Resample(grid, InterpLinear, ExtrapLinear)
+	if d := got.DataSeries[3]; !almostEq(d.Meas, 60, 1e-9) || d.Status != StImputed {
+		t.Fatalf("ExtrapLinear: got (%v,%v), want (60,StImputed)", d.Meas, d.Status)
+	}
+	got, _ = ts.Resample(grid, InterpLinear, ExtrapHold)
+	if d := got.DataSeries[0]; d.Meas != 0 || d.Status != StImputed {
+		t.Fatalf("ExtrapHold: got (%v,%v), want (0,StImputed)", d.Meas, d.Status)
+	}
+}
+
+func TestResample_Errors(t *testing.T) {
+	ts := quarterHourly(1, 2)
+	if _, err := ts.Resample(nil, Interp(42), ExtrapNone); err != ErrBounds {
+		t.Fatalf("unknown method: got %v, want ErrBounds", err)
+	}
+	ts.DataSeries[0], ts.DataSeries[1] = ts.DataSeries[1], ts.DataSeries[0]
+	if _, err := ts.Resample(nil, InterpLinear, ExtrapNone); err != ErrUnsorted {
+		t.Fatalf("unsorted: got %v, want ErrUnsorted", err)
+	}
+}
@@ -1,6 +1,7 @@
 package timeseries
 
 import (
+	"encoding/json"
 	"math"
 	"time"
 )
@@ -22,6 +23,7 @@ func toPtrOrNil(f float64) *float64 {
 //   - DchronNS   []int64    (time.Duration in ns)
 //   - Dmeas      []*float64 (NaN -> nil)
 //   - Status     []StatusCode
+//   - Annotations keyed by RFC 3339 timestamp
 func (ts *TimeSeries) ToJSON() *TimeSeriesJSON {
 	n := len(ts.DataSeries)
 	chron := make([]time.Time, n)
@@ -39,14 +41,15 @@ func (ts *TimeSeries) ToJSON() *TimeSeriesJSON {
 	}
 
 	return &TimeSeriesJSON{
-		Name:     ts.Name,
-		Comment:  ts.Comment,
-		Chron:    chron,
-		Meas:     meas,
-		DchronNS: dchron,
-		Dmeas:    dmeas,
-		Status:   status,
-		Stats:    ts.BasicStats.ToJSON(),
+		Name:        ts.Name,
+		Comment:     ts.Comment,
+		Chron:       chron,
+		Meas:        meas,
+		DchronNS:    dchron,
+		Dmeas:       dmeas,
+		Status:      status,
+		Stats:       ts.BasicStats.ToJSON(),
+		Annotations: annotationsToJSON(ts.Annotations),
 	}
 }
 
@@ -103,3 +106,164 @@ func (tsc *TsContainer) ToJSON() *TsContainerJSON {
 	}
 	return out
 }
+
+// MarshalJSON implements json.Marshaler: a TimeSeries is encoded as its
+// TimeSeriesJSON DTO, so that it can be embedded in payloads directly.
+// It returns ErrInfValue if a Meas or Dmeas is infinite, which JSON cannot
+// represent.
+func (ts TimeSeries) MarshalJSON() ([]byte, error) {
+	for _, d := range ts.DataSeries {
+		if math.IsInf(d.Meas, 0) || math.IsInf(d.Dmeas, 0) {
+			return nil, ErrInfValue
+		}
+	}
+	return json.Marshal(ts.ToJSON())
+}
+
+// MarshalJSON implements json.Marshaler: a TsContainer is encoded as its
+// TsContainerJSON DTO. It returns ErrInfValue like TimeSeries.MarshalJSON.
+func (tsc TsContainer) MarshalJSON() ([]byte, error) {
+	for _, ts := range tsc.Ts {
+		if ts == nil {
+			continue
+		}
+		for _, d := range ts.DataSeries {
+			if math.IsInf(d.Meas, 0) || math.IsInf(d.Dmeas, 0) {
+				return nil, ErrInfValue
+			}
+		}
+	}
+	return json.Marshal(tsc.ToJSON())
+}
+
+// jsonFloat is a float64 encoded as null when it is NaN or infinite, and
+// decoded from null as NaN.
+type jsonFloat float64
+
+// MarshalJSON implements json.Marshaler.
+func (f jsonFloat) MarshalJSON() ([]byte, error) {
+	if math.IsNaN(float64(f)) || math.IsInf(float64(f), 0) {
+		return []byte("null"), nil
+	}
+	return json.Marshal(float64(f))
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (f *jsonFloat) UnmarshalJSON(b []byte) error {
+	if string(b) == "null" {
+		*f = jsonFloat(math.NaN())
+		return nil
+	}
+	var v float64
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	*f = jsonFloat(v)
+	return nil
+}
+
+// basicStatsWire is the encoded form of BasicStatsJSON, whose float fields
+// may not be finite.
+type basicStatsWire struct {
+	Len        int       `json:"len"`
+	Chmin      time.Time `json:"chmin"`
+	ValAtChmin jsonFloat `json:"valAtChmin"`
+	Chmax      time.Time `json:"chmax"`
+	ValAtChmax jsonFloat `json:"valAtChmax"`
+	Chmed      time.Time `json:"chmed"`
+	Chmean     time.Time `json:"chmean"`
+	Chstd      time.Time `json:"chstd"`
+	Msmin      jsonFloat `json:"msmin"`
+	ChAtMsmin  time.Time `json:"chAtMsmin"`
+	Msmax      jsonFloat `json:"msmax"`
+	ChAtMsmax  time.Time `json:"chAtMsmax"`
+	Msmean     jsonFloat `json:"msmean"`
+	Msmed      jsonFloat `json:"msmed"`
+	Msstd      jsonFloat `json:"msstd"`
+	DChminNS   int64     `json:"dChmin_ns"`
+	ChAtDChmin time.Time `json:"chAtDChmin"`
+	DChmaxNS   int64     `json:"dChmax_ns"`
+	ChAtDChmax time.Time `json:"chAtDChmax"`
+	DChmeanNS  int64     `json:"dChmean_ns"`
+	DChmedNS   int64     `json:"dChmed_ns"`
+	DChstdNS   int64     `json:"dChstd_ns"`
+	DMsmin     jsonFloat `json:"dMsmin"`
+	DMsmax     jsonFloat `json:"dMsmax"`
+	DMsmed     jsonFloat `json:"dMsmed"`
+	DMsmean    jsonFloat `json:"dMsmean"`
+	DMsstd     jsonFloat `json:"dMsstd"`
+	NbreOfNaN  int       `json:"nbreOfNaN"`
+}
+
+// MarshalJSON implements json.Marshaler, encoding NaN and ±Inf as null.
+func (s BasicStatsJSON) MarshalJSON() ([]byte, error) {
+	return json.Marshal(basicStatsWire{
+		Len:        s.Len,
+		Chmin:      s.Chmin,
+		ValAtChmin: jsonFloat(s.ValAtChmin),
+		Chmax:      s.Chmax,
+		ValAtChmax: jsonFloat(s.ValAtChmax),
+		Chmed:      s.Chmed,
+		Chmean:     s.Chmean,
+		Chstd:      s.Chstd,
+		Msmin:      jsonFloat(s.Msmin),
+		ChAtMsmin:  s.ChAtMsmin,
+		Msmax:      jsonFloat(s.Msmax),
+		ChAtMsmax:  s.ChAtMsmax,
+		Msmean:     jsonFloat(s.Msmean),
+		Msmed:      jsonFloat(s.Msmed),
+		Msstd:      jsonFloat(s.Msstd),
+		DChminNS:   s.DChminNS,
+		ChAtDChmin: s.ChAtDChmin,
+		DChmaxNS:   s.DChmaxNS,
+		ChAtDChmax: s.ChAtDChmax,
+		DChmeanNS:  s.DChmeanNS,
+		DChmedNS:   s.DChmedNS,
+		DChstdNS:   s.DChstdNS,
+		DMsmin:     jsonFloat(s.DMsmin),
+		DMsmax:     jsonFloat(s.DMsmax),
+		DMsmed:     jsonFloat(s.DMsmed),
+		DMsmean:    jsonFloat(s.DMsmean),
+		DMsstd:     jsonFloat(s.DMsstd),
+		NbreOfNaN:  s.NbreOfNaN,
+	})
+}
+
+// UnmarshalJSON implements json.Unmarshaler, decoding null as NaN.
+func (s *BasicStatsJSON) UnmarshalJSON(b []byte) error {
+	var w basicStatsWire
+	if err := json.Unmarshal(b, &w); err != nil {
+		return err
+	}
+	*s = BasicStatsJSON{
+		Len:        w.Len,
+		Chmin:      w.Chmin,
+		ValAtChmin: float64(w.ValAtChmin),
+		Chmax:      w.Chmax,
+		ValAtChmax: float64(w.ValAtChmax),
+		Chmed:      w.Chmed,
+		Chmean:     w.Chmean,
+		Chstd:      w.Chstd,
+		Msmin:      float64(w.Msmin),
+		ChAtMsmin:  w.ChAtMsmin,
+		Msmax:      float64(w.Msmax),
+		ChAtMsmax:  w.ChAtMsmax,
+		Msmean:     float64(w.Msmean),
+		Msmed:      float64(w.Msmed),
+		Msstd:      float64(w.Msstd),
+		DChminNS:   w.DChminNS,
+		ChAtDChmin: w.ChAtDChmin,
+		DChmaxNS:   w.DChmaxNS,
+		ChAtDChmax: w.ChAtDChmax,
+		DChmeanNS:  w.DChmeanNS,
+		DChmedNS:   w.DChmedNS,
+		DChstdNS:   w.DChstdNS,
+		DMsmin:     float64(w.DMsmin),
+		DMsmax:     float64(w.DMsmax),
+		DMsmed:     float64(w.DMsmed),
+		DMsmean:    float64(w.DMsmean),
+		DMsstd:     float64(w.DMsstd),
+		NbreOfNaN:  w.NbreOfNaN,
+	}
+	return nil
+}
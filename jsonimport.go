@@ -0,0 +1,158 @@
+package timeseries
+
+import (
+	"encoding/json"
+	"math"
+	"time"
+)
+
+// fromPtrOrNaN converts a *float64 back to float64, mapping nil to NaN.
+func fromPtrOrNaN(p *float64) float64 {
+	if p == nil {
+		return math.NaN()
+	}
+	return *p
+}
+
+// FromJSON rebuilds a TimeSeries from its DTO, the inverse of ToJSON.
+// A nil Meas becomes NaN with status StMissing (or worse, if Status says
+// so); a nil Dmeas becomes NaN. DchronNS, Dmeas and Status may be omitted.
+//
+// Errors:
+//   - ErrSize if Meas, or a non-empty DchronNS, Dmeas or Status, does not
+//     have one entry per Chron.
+//   - ErrBounds if a Status is not a known StatusCode.
+//   - a time parse error if an annotation key is not RFC 3339.
+func (j *TimeSeriesJSON) FromJSON() (TimeSeries, error) {
+	n := len(j.Chron)
+	if len(j.Meas) != n {
+		return TimeSeries{}, ErrSize
+	}
+	for _, l := range []int{len(j.DchronNS), len(j.Dmeas), len(j.Status)} {
+		if l != 0 && l != n {
+			return TimeSeries{}, ErrSize
+		}
+	}
+
+	ts := TimeSeries{Name: j.Name, Comment: j.Comment, DataSeries: make([]DataUnit, n)}
+	for i, t := range j.Chron {
+		du := DataUnit{Chron: t, Meas: fromPtrOrNaN(j.Meas[i])}
+		if len(j.DchronNS) == n {
+			du.Dchron = time.Duration(j.DchronNS[i])
+		}
+		if len(j.Dmeas) == n {
+			du.Dmeas = fromPtrOrNaN(j.Dmeas[i])
+		}
+		if len(j.Status) == n {
+			if j.Status[i] > StImputed {
+				return TimeSeries{}, ErrBounds
+			}
+			du.Status = j.Status[i]
+		}
+		if j.Meas[i] == nil {
+			du.Status = worstStatus(du.Status, StMissing)
+		}
+		ts.DataSeries[i] = du
+	}
+	ts.BasicStats = j.Stats.FromJSON()
+
+	for k, list := range j.Annotations {
+		t, err := time.Parse(time.RFC3339Nano, k)
+		if err != nil {
+			return TimeSeries{}, err
+		}
+		if ts.Annotations == nil {
+			ts.Annotations = make(map[int64][]Annotation, len(j.Annotations))
+		}
+		ts.Annotations[t.UnixNano()] = append(ts.Annotations[t.UnixNano()], list...)
+	}
+	return ts, nil
+}
+
+// FromJSON rebuilds BasicStats from its DTO, the inverse of ToJSON. A nil
+// receiver gives zero stats.
+func (s *BasicStatsJSON) FromJSON() BasicStats {
+	if s == nil {
+		return BasicStats{}
+	}
+	return BasicStats{
+		Len:        s.Len,
+		Chmin:      s.Chmin,
+		ValAtChmin: s.ValAtChmin,
+		Chmax:      s.Chmax,
+		ValAtChmax: s.ValAtChmax,
+		Chmed:      s.Chmed,
+		Chmean:     s.Chmean,
+		Chstd:      s.Chstd,
+		Msmin:      s.Msmin,
+		ChAtMsmin:  s.ChAtMsmin,
+		Msmax:      s.Msmax,
+		ChAtMsmax:  s.ChAtMsmax,
+		Msmean:     s.Msmean,
+		Msmed:      s.Msmed,
+		Msstd:      s.Msstd,
+
+		DChmin:     time.Duration(s.DChminNS),
+		ChAtDChmin: s.ChAtDChmin,
+		DChmax:     time.Duration(s.DChmaxNS),
+		ChAtDchmax: s.ChAtDChmax,
+		DChmean:    time.Duration(s.DChmeanNS),
+		DChmed:     time.Duration(s.DChmedNS),
+		DChstd:     time.Duration(s.DChstdNS),
+
+		DMsmin:    s.DMsmin,
+		DMsmax:    s.DMsmax,
+		DMsmed:    s.DMsmed,
+		DMsmean:   s.DMsmean,
+		DMsstd:    s.DMsstd,
+		NbreOfNaN: s.NbreOfNaN,
+	}
+}
+
+// FromJSON rebuilds a TsContainer from its DTO, the inverse of ToJSON. Nil
+// series are skipped. It returns the first error of TimeSeriesJSON.FromJSON.
+func (j *TsContainerJSON) FromJSON() (TsContainer, error) {
+	tsc := NewTsContainer()
+	tsc.Name, tsc.Comment = j.Name, j.Comment
+	for k, v := range j.Series {
+		if v == nil {
+			continue
+		}
+		ts, err := v.FromJSON()
+		if err != nil {
+			return NewTsContainer(), err
+		}
+		tsc.Ts[k] = &ts
+	}
+	return tsc, nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler, decoding the TimeSeriesJSON
+// DTO written by MarshalJSON.
+func (ts *TimeSeries) UnmarshalJSON(b []byte) error {
+	var j TimeSeriesJSON
+	if err := json.Unmarshal(b, &j); err != nil {
+		return err
+	}
+	out, err := j.FromJSON()
+	if err != nil {
+		return err
+	}
+	*ts = out
+	return nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler, decoding the TsContainerJSON
+// DTO written by MarshalJSON.
+func (tsc *TsContainer) UnmarshalJSON(b []byte) error {
+	var j TsContainerJSON
+	if err := json.Unmarshal(b, &j); err != nil {
+		return err
+	}
+	out, err := j.FromJSON()
+	if err != nil {
+		return err
+	}
+	*tsc = out
+	return nil
+}
@@ -0,0 +1,156 @@
+package timeseries
+
+import (
+	"encoding/json"
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTimeSeriesJSON_FromJSON_RoundTrip(t *testing.T) {
+	ts := mkTS(1, math.NaN(), 3)
+	ts.DataSeries[1].Status = StMissing
+	ts.DataSeries[2].Status = StOutlier
+	ts.DeltasFiller()
+	ts.Comment = "probe"
+	ts.Msmean = 2
+	ts.DChmax = time.Minute
+	ts.Annotate(ts.DataSeries[2].Chron, Annotation{Source: "manual", Reason: "spike", At: ts.DataSeries[0].Chron})
+
+	back, err := ts.ToJSON().FromJSON()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if back.Name != ts.Name || back.Comment != "probe" || back.Msmean != 2 || back.DChmax != time.Minute {
+		t.Fatalf("metadata or stats lost: %+v", back)
+	}
+	for i, d := range back.DataSeries {
+		o := ts.DataSeries[i]
+		if !d.Chron.Equal(o.Chron) || d.Status != o.Status || d.Dchron != o.Dchron ||
+			(d.Meas != o.Meas && !(math.IsNaN(d.Meas) && math.IsNaN(o.Meas))) {
+			t.Fatalf("row %d: got %+v, want %+v", i, d, o)
+		}
+	}
+	if got := back.AnnotationsAt(ts.DataSeries[2].Chron); len(got) != 1 || got[0].Reason != "spike" {
+		t.Fatalf("annotations lost: %+v", got)
+	}
+}
+
+func TestTimeSeriesJSON_FromJSON_NilAndSizes(t *testing.T) {
+	v := 4.0
+	t0 := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	j := TimeSeriesJSON{Name: "x", Chron: []time.Time{t0, t0.Add(time.Hour)}, Meas: []*float64{nil, &v}}
+	ts, err := j.FromJSON()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !math.IsNaN(ts.DataSeries[0].Meas) || ts.DataSeries[0].Status != StMissing || ts.DataSeries[1].Status != StOK {
+		t.Fatalf("nil must become NaN and StMissing: %+v", ts.DataSeries)
+	}
+
+	j.Status = []StatusCode{StOK}
+	if _, err := j.FromJSON(); err != ErrSize {
+		t.Fatalf("expected ErrSize for short status, got %v", err)
+	}
+	j.Status, j.Meas = nil, j.Meas[:1]
+	if _, err := j.FromJSON(); err != ErrSize {
+		t.Fatalf("expected ErrSize for short meas, got %v", err)
+	}
+}
+
+func TestTimeSeries_MarshalUnmarshalJSON(t *testing.T) {
+	type payload struct {
+		Site   string      `json:"site"`
+		Series TimeSeries  `json:"series"`
+		All    TsContainer `json:"all"`
+	}
+	ts := mkTS(1, 2)
+	ts.Name = "load"
+	tsc := NewTsContainer()
+	tsc.Name = "plant"
+	tsc.Ts["load"] = &ts
+
+	b, err := json.Marshal(payload{Site: "A", Series: ts, All: tsc})
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	var back payload
+	if err := json.Unmarshal(b, &back); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if back.Series.Name != "load" || len(back.Series.DataSeries) != 2 || back.Series.DataSeries[1].Meas != 2 {
+		t.Fatalf("series not decoded: %+v", back.Series)
+	}
+	if back.All.Name != "plant" || back.All.Ts["load"] == nil || len(back.All.Ts["load"].DataSeries) != 2 {
+		t.Fatalf("container not decoded: %+v", back.All)
+	}
+
+	if err := json.Unmarshal([]byte(`{"name":"x","chron":["2025-01-01T00:00:00Z"],"meas":[]}`), &back.Series); err != ErrSize {
+		t.Fatalf("expected ErrSize, got %v", err)
+	}
+}
+
+func TestTimeSeries_MarshalJSON_LeadingMissing(t *testing.T) {
+	ts := mkTS(math.NaN(), 2, 5)
+	ts.DataSeries[0].Status = StMissing
+	ts.DeltasFiller()
+	ts.ComputeBasicStats()
+
+	b, err := json.Marshal(&ts)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	var back TimeSeries
+	if err := json.Unmarshal(b, &back); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if len(back.DataSeries) != 3 || back.DataSeries[0].Status != StMissing || !math.IsNaN(back.DataSeries[0].Meas) {
+		t.Fatalf("leading missing point lost: %+v", back.DataSeries)
+	}
+	if back.DataSeries[2].Meas != 5 || back.Len != ts.Len {
+		t.Fatalf("unexpected round trip: %+v", back)
+	}
+	for _, pair := range [][2]float64{{back.ValAtChmin, ts.ValAtChmin}, {back.Msmax, ts.Msmax}, {back.Msmean, ts.Msmean}} {
+		if pair[0] != pair[1] && !(math.IsNaN(pair[0]) && math.IsNaN(pair[1])) {
+			t.Fatalf("stats not preserved: got %v, want %v", pair[0], pair[1])
+		}
+	}
+}
+
+func TestTimeSeries_MarshalJSON_RejectsInfAndBadStatus(t *testing.T) {
+	ts := mkTS(1, math.Inf(1))
+	if _, err := json.Marshal(ts); err == nil || !strings.Contains(err.Error(), ErrInfValue.Error()) {
+		t.Fatalf("expected ErrInfValue, got %v", err)
+	}
+	tsc := NewTsContainer()
+	tsc.Ts["x"] = &ts
+	if _, err := tsc.MarshalJSON(); err != ErrInfValue {
+		t.Fatalf("expected ErrInfValue from the container, got %v", err)
+	}
+
+	v := 1.0
+	j := TimeSeriesJSON{Chron: []time.Time{time.Unix(0, 0)}, Meas: []*float64{&v}, Status: []StatusCode{StImputed + 1}}
+	if _, err := j.FromJSON(); err != ErrBounds {
+		t.Fatalf("expected ErrBounds for an unknown status, got %v", err)
+	}
+}
+
+func TestBasicStatsJSON_NonFiniteAsNull(t *testing.T) {
+	in := BasicStatsJSON{Len: 1, Msmin: math.NaN(), Msmax: math.Inf(1), Msmean: 2.5}
+	b, err := json.Marshal(in)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	if !strings.Contains(string(b), `"msmin":null`) || !strings.Contains(string(b), `"msmax":null`) ||
+		!strings.Contains(string(b), `"msmean":2.5`) {
+		t.Fatalf("unexpected encoding %s", b)
+	}
+	var back BasicStatsJSON
+	if err := json.Unmarshal(b, &back); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if !math.IsNaN(back.Msmin) || !math.IsNaN(back.Msmax) || back.Msmean != 2.5 || back.Len != 1 {
+		t.Fatalf("unexpected round trip %+v", back)
+	}
+}
@@ -54,6 +54,7 @@ func (ts *TimeSeries) Copy() TimeSeries {
 	for _, value := range ts.DataSeries {
 		copyofts.DataSeries = append(copyofts.DataSeries, value)
 	}
+	copyofts.Annotations = ts.copyAnnotations()
 	copyofts.Sort_Deltas_Stats()
 	return copyofts
 }
@@ -64,6 +65,17 @@ func (s *TimeSeries) SortChrono() {
 	})
 }
 
+// strictlyIncreasing reports whether Chron is strictly increasing along the
+// series (no duplicates, no regressions).
+func (ts *TimeSeries) strictlyIncreasing() bool {
+	for i := 1; i < len(ts.DataSeries); i++ {
+		if !ts.DataSeries[i].Chron.After(ts.DataSeries[i-1].Chron) {
+			return false
+		}
+	}
+	return true
+}
+
 // DeltasFiller is used in Sort_Deltas_Stats method. No need to use it alone. Warning. Does not sort data in chrnological order.
 // Once Sort_Deltas_Stats function is applied, data are sorted.
 func (ts *TimeSeries) DeltasFiller() {
@@ -0,0 +1,239 @@
+package timeseries
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// NDJSON (JSON Lines) streams a series as one object per line, so that
+// series far larger than memory can be piped between services:
+//
+//	{"t":"2025-01-01T00:00:00Z","v":21.5,"s":0}
+//	{"t":"2025-01-01T00:00:01Z","v":null,"s":1}
+//
+// t is an RFC 3339 timestamp, v the value (null for NaN) and s the
+// StatusCode. Container streams add the series key k to every line:
+//
+//	{"k":"load","t":"2025-01-01T00:00:00Z","v":3.2,"s":0}
+//
+// Only Chron, Meas and Status travel; recompute deltas with DeltasFiller.
+
+// maxNDJSONLine bounds the length of a decoded line.
+const maxNDJSONLine = 1 << 20
+
+// ndjsonRecord is the decoded form of one line.
+type ndjsonRecord struct {
+	K string     `json:"k"`
+	T *time.Time `json:"t"`
+	V *float64   `json:"v"`
+	S StatusCode `json:"s"`
+}
+
+// NDJSONEncoder writes NDJSON records to a stream. It buffers its output:
+// call Flush when done. Use NewNDJSONEncoder to create one.
+type NDJSONEncoder struct {
+	w       *bufio.Writer
+	buf     []byte
+	lastKey string
+	keyJSON []byte
+}
+
+// NewNDJSONEncoder returns an encoder writing to w.
+func NewNDJSONEncoder(w io.Writer) *NDJSONEncoder {
+	return &NDJSONEncoder{w: bufio.NewWriter(w)}
+}
+
+// Encode writes one record. key is the series key of container streams;
+// "" omits it. It returns ErrInfValue for an infinite value, which JSON
+// cannot represent.
+func (e *NDJSONEncoder) Encode(key string, d DataUnit) error {
+	if math.IsInf(d.Meas, 0) {
+		return ErrInfValue
+	}
+	b := append(e.buf[:0], '{')
+	if key != "" {
+		if key != e.lastKey || e.keyJSON == nil {
+			kj, err := json.Marshal(key)
+			if err != nil {
+				return err
+			}
+			e.lastKey, e.keyJSON = key, kj
+		}
+		b = append(b, `"k":`...)
+		b = append(b, e.keyJSON...)
+		b = append(b, ',')
+	}
+	b = append(b, `"t":"`...)
+	b = d.Chron.AppendFormat(b, time.RFC3339Nano)
+	b = append(b, `","v":`...)
+	if math.IsNaN(d.Meas) {
+		b = append(b, "null"...)
+	} else {
+		b = strconv.AppendFloat(b, d.Meas, 'g', -1, 64)
+	}
+	b = append(b, `,"s":`...)
+	b = strconv.AppendInt(b, int64(d.Status), 10)
+	b = append(b, "}\n"...)
+	e.buf = b
+	_, err := e.w.Write(b)
+	return err
+}
+
+// EncodeSeries writes every point of ts, without series key.
+func (e *NDJSONEncoder) EncodeSeries(ts *TimeSeries) error {
+	for _, d := range ts.DataSeries {
+		if err := e.Encode("", d); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// EncodeContainer writes every series of tsc, keyed by its name in the
+// container, series after series in lexical order of the keys.
+func (e *NDJSONEncoder) EncodeContainer(tsc *TsContainer) error {
+	keys := make([]string, 0, len(tsc.Ts))
+	for k, ts := range tsc.Ts {
+		if ts != nil {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		for _, d := range tsc.Ts[k].DataSeries {
+			if err := e.Encode(k, d); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Flush writes any buffered data to the underlying writer.
+func (e *NDJSONEncoder) Flush() error {
+	return e.w.Flush()
+}
+
+// NDJSONDecoder reads NDJSON records from a stream, one line at a time,
+// so that its memory use does not depend on the stream length. Blank lines
+// are skipped. Use NewNDJSONDecoder to create one.
+type NDJSONDecoder struct {
+	sc   *bufio.Scanner
+	line int
+}
+
+// NewNDJSONDecoder returns a decoder reading from r.
+func NewNDJSONDecoder(r io.Reader) *NDJSONDecoder {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 4096), maxNDJSONLine)
+	return &NDJSONDecoder{sc: sc}
+}
+
+// Next decodes the next record and returns its series key ("" if absent)
+// and point. A null or absent v gives NaN with status StMissing (or worse).
+// At the end of the stream it returns io.EOF. Decoding errors mention the
+// line number; as in TimeSeriesJSON.FromJSON, a record without t wraps
+// ErrSize and an unknown status wraps ErrBounds.
+func (dec *NDJSONDecoder) Next() (string, DataUnit, error) {
+	for dec.sc.Scan() {
+		dec.line++
+		b := dec.sc.Bytes()
+		if isBlank(b) {
+			continue
+		}
+		var rec ndjsonRecord
+		if err := json.Unmarshal(b, &rec); err != nil {
+			return "", DataUnit{}, fmt.Errorf("ndjson line %d: %w", dec.line, err)
+		}
+		if rec.T == nil {
+			return "", DataUnit{}, fmt.Errorf("ndjson line %d: missing \"t\": %w", dec.line, ErrSize)
+		}
+		if rec.S > StImputed {
+			return "", DataUnit{}, fmt.Errorf("ndjson line %d: status %d: %w", dec.line, rec.S, ErrBounds)
+		}
+		d := DataUnit{Chron: *rec.T, Meas: fromPtrOrNaN(rec.V), Status: rec.S}
+		if rec.V == nil {
+			d.Status = worstStatus(d.Status, StMissing)
+		}
+		return rec.K, d, nil
+	}
+	if err := dec.sc.Err(); err != nil {
+		return "", DataUnit{}, err
+	}
+	return "", DataUnit{}, io.EOF
+}
+
+// isBlank reports whether b holds only JSON whitespace.
+func isBlank(b []byte) bool {
+	for _, c := range b {
+		if c != ' ' && c != '\t' && c != '\r' {
+			return false
+		}
+	}
+	return true
+}
+
+// WriteNDJSON writes the series to w as NDJSON records without key.
+func (ts *TimeSeries) WriteNDJSON(w io.Writer) error {
+	e := NewNDJSONEncoder(w)
+	if err := e.EncodeSeries(ts); err != nil {
+		return err
+	}
+	return e.Flush()
+}
+
+// WriteNDJSON writes the container to w as keyed NDJSON records (see
+// NDJSONEncoder.EncodeContainer).
+func (tsc *TsContainer) WriteNDJSON(w io.Writer) error {
+	e := NewNDJSONEncoder(w)
+	if err := e.EncodeContainer(tsc); err != nil {
+		return err
+	}
+	return e.Flush()
+}
+
+// ReadNDJSON reads a whole NDJSON stream into one series named name,
+// ignoring series keys. Use NDJSONDecoder to process a stream that does
+// not fit in memory.
+func ReadNDJSON(r io.Reader, name string) (TimeSeries, error) {
+	ts := TimeSeries{Name: name}
+	dec := NewNDJSONDecoder(r)
+	for {
+		_, d, err := dec.Next()
+		if err == io.EOF {
+			return ts, nil
+		}
+		if err != nil {
+			return TimeSeries{}, err
+		}
+		ts.AddDataUnit(d)
+	}
+}
+
+// ReadNDJSONContainer reads a whole keyed NDJSON stream into a container
+// with one series per key, in stream order. Lines may interleave keys.
+func ReadNDJSONContainer(r io.Reader) (TsContainer, error) {
+	tsc := NewTsContainer()
+	dec := NewNDJSONDecoder(r)
+	for {
+		k, d, err := dec.Next()
+		if err == io.EOF {
+			return tsc, nil
+		}
+		if err != nil {
+			return NewTsContainer(), err
+		}
+		ts := tsc.Ts[k]
+		if ts == nil {
+			ts = &TimeSeries{Name: k}
+			tsc.Ts[k] = ts
+		}
+		ts.AddDataUnit(d)
+	}
+}
@@ -0,0 +1,104 @@
+package timeseries
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestNDJSON_SeriesRoundTrip(t *testing.T) {
+	ts := mkTS(1.5, math.NaN(), -2)
+	ts.DataSeries[1].Status = StMissing
+	ts.DataSeries[2].Status = StOutlier
+
+	var buf bytes.Buffer
+	if err := ts.WriteNDJSON(&buf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := `{"t":"2025-01-01T12:00:00Z","v":1.5,"s":0}` + "\n" +
+		`{"t":"2025-01-01T12:01:00Z","v":null,"s":1}` + "\n" +
+		`{"t":"2025-01-01T12:02:00Z","v":-2,"s":2}` + "\n"
+	if buf.String() != want {
+		t.Fatalf("got\n%s\nwant\n%s", buf.String(), want)
+	}
+
+	back, err := ReadNDJSON(&buf, "back")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if back.Name != "back" || len(back.DataSeries) != 3 {
+		t.Fatalf("unexpected series %+v", back)
+	}
+	for i, d := range back.DataSeries {
+		o := ts.DataSeries[i]
+		if !d.Chron.Equal(o.Chron) || d.Status != o.Status || (d.Meas != o.Meas && !math.IsNaN(o.Meas)) {
+			t.Fatalf("row %d: got %+v, want %+v", i, d, o)
+		}
+	}
+}
+
+func TestNDJSON_Container(t *testing.T) {
+	a, b := mkTS(1, 2), mkTS(10)
+	tsc := NewTsContainer()
+	tsc.Ts["a"], tsc.Ts["b\"q"] = &a, &b
+
+	var buf bytes.Buffer
+	if err := tsc.WriteNDJSON(&buf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.HasPrefix(buf.String(), `{"k":"a",`) || !strings.Contains(buf.String(), `{"k":"b\"q",`) {
+		t.Fatalf("unexpected stream\n%s", buf.String())
+	}
+	back, err := ReadNDJSONContainer(&buf)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(back.Ts) != 2 || len(back.Ts["a"].DataSeries) != 2 || back.Ts["b\"q"].DataSeries[0].Meas != 10 {
+		t.Fatalf("unexpected container %+v", back.Ts)
+	}
+}
+
+func TestNDJSONDecoder_Next(t *testing.T) {
+	in := `{"t":"2025-01-01T00:00:00Z","v":1}` + "\n\n" +
+		`{"t":"2025-01-01T00:00:01Z"}` + "\n" +
+		`{"t":"oops"}` + "\n"
+	dec := NewNDJSONDecoder(strings.NewReader(in))
+	if _, d, err := dec.Next(); err != nil || d.Meas != 1 || d.Status != StOK {
+		t.Fatalf("first record: %+v, %v", d, err)
+	}
+	if _, d, err := dec.Next(); err != nil || !math.IsNaN(d.Meas) || d.Status != StMissing {
+		t.Fatalf("absent v must be missing: %+v, %v", d, err)
+	}
+	if _, _, err := dec.Next(); err == nil || !strings.Contains(err.Error(), "line 4") {
+		t.Fatalf("expected an error on line 4, got %v", err)
+	}
+	if _, _, err := dec.Next(); err != io.EOF {
+		t.Fatalf("expected io.EOF, got %v", err)
+	}
+}
+
+func TestNDJSONDecoder_RejectsBadRecords(t *testing.T) {
+	cases := []struct {
+		line string
+		want error
+	}{
+		{`{"v":1,"s":0}`, ErrSize},
+		{`{"t":"2025-01-01T00:00:00Z","v":1,"s":42}`, ErrBounds},
+	}
+	for _, c := range cases {
+		_, _, err := NewNDJSONDecoder(strings.NewReader(c.line + "\n")).Next()
+		if !errors.Is(err, c.want) || !strings.Contains(err.Error(), "line 1") {
+			t.Fatalf("%s: got %v, want %v", c.line, err, c.want)
+		}
+	}
+}
+
+func TestNDJSONEncoder_Inf(t *testing.T) {
+	e := NewNDJSONEncoder(io.Discard)
+	if err := e.Encode("", DataUnit{Meas: math.Inf(1)}); err != ErrInfValue {
+		t.Fatalf("expected ErrInfValue, got %v", err)
+	}
+}
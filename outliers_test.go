@@ -276,3 +276,47 @@ func TestPercentile_Sanity(t *testing.T) {
 		}
 	}
 }
+
+func TestPeirceRatio_MatchesRossTable(t *testing.T) {
+	cases := []struct {
+		N, n int
+		want float64
+	}{
+		{4, 1, 1.383}, {4, 2, 1.078}, {10, 1, 1.878}, {10, 2, 1.570},
+		{10, 5, 1.114}, {20, 9, 1.190}, {60, 1, 2.663}, {60, 3, 2.237},
+	}
+	for _, c := range cases {
+		if got := PeirceRatio(c.N, c.n, 1); math.Abs(got-c.want) > 1e-3 {
+			t.Fatalf("R(%d, %d) = %.4f, want %.3f", c.N, c.n, got, c.want)
+		}
+	}
+	if got := Rtable(7, 1); math.Abs(got-1.570) > 1e-3 {
+		t.Fatalf("Rtable(7, 1) = %v, want 1.570", got)
+	}
+	if !math.IsNaN(PeirceRatio(3, 2, 1)) || !math.IsNaN(PeirceRatio(5, 0, 1)) {
+		t.Fatal("out of range arguments must give NaN")
+	}
+	// Large samples no longer overflow.
+	if r := PeirceRatio(5000, 20, 1); math.IsNaN(r) || r < 3 || r > 4.5 {
+		t.Fatalf("R(5000, 20) = %v", r)
+	}
+}
+
+func TestPeirce_LargeSampleManyOutliers(t *testing.T) {
+	vals := make([]float64, 3000)
+	for i := range vals {
+		vals[i] = math.Sin(float64(i)) // bounded, roughly arcsine distributed
+	}
+	for i := 0; i < 15; i++ {
+		vals[i*200] = 50 + float64(i)
+	}
+	rej := Peirce(vals)
+	if len(rej) != 15 {
+		t.Fatalf("rejected %d points, want 15", len(rej))
+	}
+	for _, i := range rej {
+		if i%200 != 0 {
+			t.Fatalf("unexpected rejection at %d", i)
+		}
+	}
+}
@@ -0,0 +1,295 @@
+package timeseries
+
+import (
+	"math"
+	"sort"
+)
+
+// OutlierTest reports the outcome of a named statistical outlier test, with
+// what is needed to cite it: the statistic and critical value of every step.
+//
+// Fields:
+//   - Test:       "grubbs", "gesd", "chauvenet" or "dixon".
+//   - Alpha:      the significance level (0 for Chauvenet, which has none).
+//   - Candidates: the index of the observation examined at each step.
+//   - Statistics: the test statistic at each step.
+//   - Critical:   the critical value at each step; the candidate is an
+//     outlier when the statistic exceeds it.
+//   - Rejected:   the indices of the observations declared outliers.
+//
+// Indices refer to the input slice.
+type OutlierTest struct {
+	Test       string
+	Alpha      float64
+	Candidates []int
+	Statistics []float64
+	Critical   []float64
+	Rejected   []int
+}
+
+// meanSampleStd returns the mean and the sample standard deviation
+// (denominator n-1) of the values of data at idx.
+func meanSampleStd(data []float64, idx []int) (float64, float64) {
+	var sum float64
+	for _, i := range idx {
+		sum += data[i]
+	}
+	mean := sum / float64(len(idx))
+	var ss float64
+	for _, i := range idx {
+		ss += (data[i] - mean) * (data[i] - mean)
+	}
+	return mean, math.Sqrt(ss / float64(len(idx)-1))
+}
+
+// extreme returns the position in idx of the observation farthest from mean
+// on the given tail, and its studentized deviation.
+func extreme(data []float64, idx []int, mean, std float64, tail Tail) (int, float64) {
+	best, stat := 0, math.Inf(-1)
+	for k, i := range idx {
+		dev := data[i] - mean
+		switch tail {
+		case TailBoth:
+			dev = math.Abs(dev)
+		case TailLower:
+			dev = -dev
+		}
+		if dev > stat {
+			best, stat = k, dev
+		}
+	}
+	if std == 0 {
+		return best, 0
+	}
+	return best, stat / std
+}
+
+// grubbsCritical returns the critical value of the Grubbs statistic for n
+// observations at significance level p per tail examined.
+func grubbsCritical(n int, p float64) float64 {
+	fn := float64(n)
+	t := studentTQuantile(1-p, fn-2)
+	return (fn - 1) / math.Sqrt(fn) * math.Sqrt(t*t/(fn-2+t*t))
+}
+
+// Grubbs runs Grubbs' test for a single outlier at significance level
+// alpha: G = max deviation / sample standard deviation, compared with
+//
+//	G_crit = (n-1)/√n · √(t²/(n-2+t²))
+//
+// where t is the upper α/(2n) (two-sided) or α/n (one-sided) quantile of
+// Student's t with n-2 degrees of freedom. The data are assumed normal.
+//
+// Errors:
+//   - ErrSize if data has fewer than 3 values.
+//   - ErrBounds if alpha is not in (0, 1) or tail is unknown.
+//   - ErrNaN if data holds a NaN.
+func Grubbs(data []float64, alpha float64, tail Tail) (OutlierTest, error) {
+	res := OutlierTest{Test: "grubbs", Alpha: alpha}
+	if err := checkTestInput(data, alpha, 3); err != nil {
+		return res, err
+	}
+	if tail < TailBoth || tail > TailLower {
+		return res, ErrBounds
+	}
+	idx := allIndices(len(data))
+	mean, std := meanSampleStd(data, idx)
+	k, g := extreme(data, idx, mean, std, tail)
+	p := alpha / float64(len(data))
+	if tail == TailBoth {
+		p /= 2
+	}
+	crit := grubbsCritical(len(data), p)
+	res.Candidates = []int{k}
+	res.Statistics = []float64{g}
+	res.Critical = []float64{crit}
+	if g > crit {
+		res.Rejected = []int{k}
+	}
+	return res, nil
+}
+
+// GESD runs Rosner's generalized extreme studentized deviate test for up to
+// maxOutliers outliers at significance level alpha. At step i it removes
+// the observation farthest from the mean of the remaining ones, with
+// statistic R_i = max|x - mean| / s, and critical value
+//
+//	λ_i = (n-i) t / √((n-i-1+t²)(n-i+1)),  t = t(1 - α/(2(n-i+1)), n-i-1)
+//
+// The number of outliers is the largest i with R_i > λ_i; they are the first
+// i candidates. Unlike repeated Grubbs tests, GESD is not fooled by masking.
+//
+// Errors:
+//   - ErrSize if data has fewer than 3 values.
+//   - ErrBounds if alpha is not in (0, 1) or maxOutliers is not in
+//     [1, n-2].
+//   - ErrNaN if data holds a NaN.
+func GESD(data []float64, maxOutliers int, alpha float64) (OutlierTest, error) {
+	res := OutlierTest{Test: "gesd", Alpha: alpha}
+	if err := checkTestInput(data, alpha, 3); err != nil {
+		return res, err
+	}
+	n := len(data)
+	if maxOutliers < 1 || maxOutliers > n-2 {
+		return res, ErrBounds
+	}
+	return gesd(data, maxOutliers, alpha, TailBoth, false), nil
+}
+
+// gesd runs the generalized ESD steps. With robust set, deviations are
+// measured from the median and scaled by 1.4826*MAD instead of the mean and
+// standard deviation (the mean absolute deviation when MAD is 0). A
+// one-sided tail uses α/(n-i+1) instead of α/(2(n-i+1)).
+func gesd(data []float64, maxOutliers int, alpha float64, tail Tail, robust bool) OutlierTest {
+	res := OutlierTest{Test: "gesd", Alpha: alpha}
+	n := len(data)
+	remaining := allIndices(n)
+	outliers := 0
+	for i := 1; i <= maxOutliers; i++ {
+		center, scale := meanSampleStd(data, remaining)
+		if robust {
+			vals := make([]float64, len(remaining))
+			for k, j := range remaining {
+				vals[k] = data[j]
+			}
+			mad, med, _ := MAD(vals)
+			center, scale = med, madScale*mad
+			if mad == 0 {
+				// Mostly constant data: fall back on the mean absolute
+				// deviation, as MADCleaning does.
+				var meanAD float64
+				for _, v := range vals {
+					meanAD += math.Abs(v - med)
+				}
+				scale = 1.253314 * meanAD / float64(len(vals))
+			}
+		}
+		k, r := extreme(data, remaining, center, scale, tail)
+		fn, fi := float64(n), float64(i)
+		p := alpha / (fn - fi + 1)
+		if tail == TailBoth {
+			p /= 2
+		}
+		t := studentTQuantile(1-p, fn-fi-1)
+		lambda := (fn - fi) * t / math.Sqrt((fn-fi-1+t*t)*(fn-fi+1))
+
+		res.Candidates = append(res.Candidates, remaining[k])
+		res.Statistics = append(res.Statistics, r)
+		res.Critical = append(res.Critical, lambda)
+		if r > lambda {
+			outliers = i
+		}
+		remaining = append(remaining[:k], remaining[k+1:]...)
+	}
+	res.Rejected = append([]int(nil), res.Candidates[:outliers]...)
+	return res
+}
+
+// Chauvenet applies Chauvenet's criterion: an observation is rejected when
+// the expected number of observations at least as far from the mean,
+// n·erfc(|z|/√2), is below one half. This amounts to |z| > z_c with
+// z_c = √2·erfc⁻¹(1/(2n)), z being studentized with the sample standard
+// deviation. The criterion is applied once, as recommended; the report
+// holds a single step for the most extreme observation.
+//
+// Errors:
+//   - ErrSize if data has fewer than 3 values.
+//   - ErrNaN if data holds a NaN.
+func Chauvenet(data []float64) (OutlierTest, error) {
+	res := OutlierTest{Test: "chauvenet"}
+	if err := checkTestInput(data, 0.5, 3); err != nil {
+		return res, err
+	}
+	n := len(data)
+	idx := allIndices(n)
+	mean, std := meanSampleStd(data, idx)
+	k, z := extreme(data, idx, mean, std, TailBoth)
+	crit := math.Sqrt2 * math.Erfcinv(0.5/float64(n))
+	res.Candidates = []int{k}
+	res.Statistics = []float64{z}
+	res.Critical = []float64{crit}
+	if std > 0 {
+		for i, v := range data {
+			if math.Abs(v-mean)/std > crit {
+				res.Rejected = append(res.Rejected, i)
+			}
+		}
+	}
+	return res, nil
+}
+
+// dixonTable holds the two-sided critical values of Dixon's Q (r10) for
+// n = 3..10 at α = 0.10, 0.05 and 0.01 (Rorabacher, 1991).
+var dixonTable = map[float64][8]float64{
+	0.10: {0.941, 0.765, 0.642, 0.560, 0.507, 0.468, 0.437, 0.412},
+	0.05: {0.970, 0.829, 0.710, 0.625, 0.568, 0.526, 0.493, 0.466},
+	0.01: {0.994, 0.926, 0.821, 0.740, 0.680, 0.634, 0.598, 0.568},
+}
+
+// DixonQ runs Dixon's Q test for small samples (3 to 10 values): the gap
+// between the most suspicious extreme value and its nearest neighbor,
+// divided by the range, is compared with the tabulated critical value.
+// Only alpha = 0.10, 0.05 and 0.01 are tabulated.
+//
+// Errors:
+//   - ErrSize if data does not have 3 to 10 values.
+//   - ErrBounds if alpha is not one of the tabulated levels.
+//   - ErrNaN if data holds a NaN.
+func DixonQ(data []float64, alpha float64) (OutlierTest, error) {
+	res := OutlierTest{Test: "dixon", Alpha: alpha}
+	if err := checkTestInput(data, alpha, 3); err != nil {
+		return res, err
+	}
+	n := len(data)
+	if n > 10 {
+		return res, ErrSize
+	}
+	table, ok := dixonTable[alpha]
+	if !ok {
+		return res, ErrBounds
+	}
+	idx := allIndices(n)
+	sort.SliceStable(idx, func(a, b int) bool { return data[idx[a]] < data[idx[b]] })
+	lo, hi := data[idx[0]], data[idx[n-1]]
+	rng := hi - lo
+	k, q := idx[n-1], 0.0
+	if rng > 0 {
+		qLow, qHigh := (data[idx[1]]-lo)/rng, (hi-data[idx[n-2]])/rng
+		q = qHigh
+		if qLow > qHigh {
+			k, q = idx[0], qLow
+		}
+	}
+	res.Candidates = []int{k}
+	res.Statistics = []float64{q}
+	res.Critical = []float64{table[n-3]}
+	if q > table[n-3] {
+		res.Rejected = []int{k}
+	}
+	return res, nil
+}
+
+// checkTestInput validates the common arguments of the outlier tests.
+func checkTestInput(data []float64, alpha float64, minSize int) error {
+	if len(data) < minSize {
+		return ErrSize
+	}
+	if alpha <= 0 || alpha >= 1 {
+		return ErrBounds
+	}
+	for _, v := range data {
+		if math.IsNaN(v) {
+			return ErrNaN
+		}
+	}
+	return nil
+}
+
+// allIndices returns 0, 1, ..., n-1.
+func allIndices(n int) []int {
+	idx := make([]int, n)
+	for i := range idx {
+		idx[i] = i
+	}
+	return idx
+}
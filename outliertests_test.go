@@ -0,0 +1,109 @@
+package timeseries
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestStudentTQuantile(t *testing.T) {
+	cases := []struct{ p, df, want float64 }{
+		{0.975, 10, 2.228139}, {0.95, 1, 6.313752}, {0.995, 30, 2.749996}, {0.025, 5, -2.570582},
+	}
+	for _, c := range cases {
+		if got := studentTQuantile(c.p, c.df); math.Abs(got-c.want) > 1e-5 {
+			t.Fatalf("t(%v, %v) = %v, want %v", c.p, c.df, got, c.want)
+		}
+	}
+}
+
+func TestGrubbs_NIST(t *testing.T) {
+	// NIST/SEMATECH e-Handbook, section 1.3.5.17.
+	data := []float64{199.31, 199.53, 200.19, 200.82, 201.92, 201.95, 202.18, 245.57}
+	res, err := Grubbs(data, 0.05, TailBoth)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if math.Abs(res.Statistics[0]-2.4687) > 1e-4 || math.Abs(res.Critical[0]-2.1266) > 1e-4 {
+		t.Fatalf("G=%v Gcrit=%v, want 2.4687 and 2.1266", res.Statistics[0], res.Critical[0])
+	}
+	if !reflect.DeepEqual(res.Rejected, []int{7}) {
+		t.Fatalf("rejected %v, want [7]", res.Rejected)
+	}
+	res, _ = Grubbs(data, 0.05, TailLower)
+	if len(res.Rejected) != 0 || res.Candidates[0] != 0 {
+		t.Fatalf("lower tail: %+v", res)
+	}
+}
+
+func TestGESD_Rosner(t *testing.T) {
+	// Rosner's data set, as in the NIST/SEMATECH e-Handbook.
+	data := []float64{-0.25, 0.68, 0.94, 1.15, 1.20, 1.26, 1.26, 1.34, 1.38, 1.43, 1.49, 1.49, 1.55, 1.56,
+		1.58, 1.65, 1.69, 1.70, 1.76, 1.77, 1.81, 1.91, 1.94, 1.96, 1.99, 2.06, 2.09, 2.10, 2.14, 2.15,
+		2.23, 2.24, 2.26, 2.35, 2.37, 2.40, 2.47, 2.54, 2.62, 2.64, 2.90, 2.92, 2.92, 2.93, 3.21, 3.26,
+		3.30, 3.59, 3.68, 4.30, 4.64, 5.34, 5.42, 6.01}
+	res, err := GESD(data, 10, 0.05)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	wantR := []float64{3.119, 2.943, 3.179, 2.810, 2.816, 2.848, 2.279, 2.310, 2.102, 2.067}
+	wantL := []float64{3.159, 3.151, 3.144, 3.136, 3.128, 3.120, 3.112, 3.103, 3.094, 3.085}
+	for i := range wantR {
+		if math.Abs(res.Statistics[i]-wantR[i]) > 1e-3 || math.Abs(res.Critical[i]-wantL[i]) > 1e-3 {
+			t.Fatalf("step %d: R=%v λ=%v, want %v and %v", i+1, res.Statistics[i], res.Critical[i], wantR[i], wantL[i])
+		}
+	}
+	// Step 3 exceeds its critical value, so three outliers despite step 2.
+	if !reflect.DeepEqual(res.Rejected, []int{53, 52, 51}) {
+		t.Fatalf("rejected %v, want [53 52 51]", res.Rejected)
+	}
+}
+
+func TestChauvenetAndDixon(t *testing.T) {
+	data := []float64{0.189, 0.167, 0.187, 0.183, 0.186, 0.182, 0.181, 0.184, 0.181, 0.177}
+	ch, err := Chauvenet(data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if math.Abs(ch.Critical[0]-1.96) > 1e-2 || !reflect.DeepEqual(ch.Rejected, []int{1}) {
+		t.Fatalf("chauvenet: %+v", ch)
+	}
+
+	q, _ := DixonQ(data, 0.05)
+	if math.Abs(q.Statistics[0]-0.4545) > 1e-3 || q.Critical[0] != 0.466 || len(q.Rejected) != 0 {
+		t.Fatalf("dixon at 0.05: %+v", q)
+	}
+	q, _ = DixonQ(data, 0.10)
+	if !reflect.DeepEqual(q.Rejected, []int{1}) {
+		t.Fatalf("dixon at 0.10: %+v", q)
+	}
+}
+
+func TestOutlierTests_Errors(t *testing.T) {
+	if _, err := Grubbs([]float64{1, 2}, 0.05, TailBoth); err != ErrSize {
+		t.Fatalf("got %v, want ErrSize", err)
+	}
+	if _, err := GESD([]float64{1, 2, 3, 4}, 3, 0.05); err != ErrBounds {
+		t.Fatalf("got %v, want ErrBounds", err)
+	}
+	if _, err := DixonQ(make([]float64, 11), 0.05); err != ErrSize {
+		t.Fatalf("got %v, want ErrSize", err)
+	}
+	if _, err := DixonQ([]float64{1, 2, 3}, 0.02); err != ErrBounds {
+		t.Fatalf("got %v, want ErrBounds", err)
+	}
+	if _, err := Chauvenet([]float64{1, math.NaN(), 3}); err != ErrNaN {
+		t.Fatalf("got %v, want ErrNaN", err)
+	}
+}
+
+func TestGESDDetector_Flag(t *testing.T) {
+	ts := mkTS(10, 11, 10, 12, 11, 10, 60, 11, 10, 12, -40, 11)
+	flags := ts.Flag(GESDDetector{MaxOutliers: 3, Alpha: 0.05})
+	if len(flags) != 2 || flags[0].Index != 6 || flags[1].Index != 10 || flags[0].Detector != "gesd" {
+		t.Fatalf("got %+v", flags)
+	}
+	if ts.DataSeries[6].Status != StOutlier || ts.DataSeries[10].Status != StOutlier {
+		t.Fatal("flagged points must be StOutlier")
+	}
+}
@@ -0,0 +1,250 @@
+package timeseries
+
+import (
+	"encoding/json"
+	"io"
+	"math"
+	"strconv"
+	"time"
+)
+
+// Duration is a time.Duration that reads and writes JSON as a duration
+// string such as "90s", "15m" or "1d12h". A plain JSON number is read as a
+// number of seconds.
+type Duration time.Duration
+
+// MarshalJSON implements json.Marshaler.
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		secs, nerr := strconv.ParseFloat(string(b), 64)
+		if nerr != nil {
+			return err
+		}
+		*d = Duration(secs * float64(time.Second))
+		return nil
+	}
+	v, err := parseDurationLit(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// QualityRules is a declarative set of sensor data-quality checks. A nil
+// rule is disabled. The zero value checks nothing. Rules are meant to be
+// tuned by operators and loaded from JSON (see LoadQualityRules), e.g.
+//
+//	{
+//	  "range":          {"min": -40, "max": 125},
+//	  "rate_of_change": {"max_delta": 5, "per": "1m"},
+//	  "flatline":       {"max_duration": "2h", "tolerance": 0.01},
+//	  "spike":          {"threshold": 10, "max_gap": "10m"},
+//	  "timestamps":     true
+//	}
+type QualityRules struct {
+	Range        *RangeRule    `json:"range,omitempty"`
+	RateOfChange *RateRule     `json:"rate_of_change,omitempty"`
+	Flatline     *FlatlineRule `json:"flatline,omitempty"`
+	Spike        *SpikeRule    `json:"spike,omitempty"`
+	Timestamps   bool          `json:"timestamps,omitempty"`
+}
+
+// RangeRule rejects values outside the physical range [Min, Max] of the
+// sensor as StInvalid.
+type RangeRule struct {
+	Min float64 `json:"min"`
+	Max float64 `json:"max"`
+}
+
+// RateRule flags as StOutlier a value that moved more than MaxDelta per
+// Per (1s if zero) since the last accepted value: the change is measured
+// against the previous valid point that was not itself flagged, over the
+// time elapsed since that point, so a rejected jump does not make the
+// return to normal look like a second one.
+type RateRule struct {
+	MaxDelta float64  `json:"max_delta"`
+	Per      Duration `json:"per,omitempty"`
+}
+
+// FlatlineRule rejects as StInvalid all the points, the first one
+// included, of a run of values that stay within Tolerance of the first
+// value of the run for more than MaxDuration: a stuck sensor.
+type FlatlineRule struct {
+	MaxDuration Duration `json:"max_duration"`
+	Tolerance   float64  `json:"tolerance,omitempty"`
+}
+
+// SpikeRule flags as StOutlier a point that jumps away from both neighbors
+// and comes back. The spike height is
+//
+//	|x - (prev+next)/2| - |next - prev|/2
+//
+// and must exceed Threshold. When MaxGap is set, only points whose two
+// neighbors are at most MaxGap away are judged.
+type SpikeRule struct {
+	Threshold float64  `json:"threshold"`
+	MaxGap    Duration `json:"max_gap,omitempty"`
+}
+
+// QualityIssue reports a point rejected by a rule: its position, its
+// timestamp, the rule and the status it received.
+type QualityIssue struct {
+	Index  int           `json:"index"`
+	Chron  time.Time     `json:"chron"`
+	Reason QualityReason `json:"reason"`
+	Status StatusCode    `json:"status"`
+}
+
+// LoadQualityRules reads a QualityRules set from JSON. Unknown fields are
+// rejected so that misspelled rules do not go unnoticed.
+func LoadQualityRules(r io.Reader) (QualityRules, error) {
+	var q QualityRules
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&q); err != nil {
+		return QualityRules{}, err
+	}
+	return q, q.validate()
+}
+
+// validate checks the rule parameters.
+func (q QualityRules) validate() error {
+	switch {
+	case q.Range != nil && q.Range.Min > q.Range.Max:
+		return ErrBounds
+	case q.RateOfChange != nil && (q.RateOfChange.MaxDelta < 0 || q.RateOfChange.Per < 0):
+		return ErrBounds
+	case q.Flatline != nil && (q.Flatline.MaxDuration <= 0 || q.Flatline.Tolerance < 0):
+		return ErrBounds
+	case q.Spike != nil && (q.Spike.Threshold < 0 || q.Spike.MaxGap < 0):
+		return ErrBounds
+	}
+	return nil
+}
+
+// Apply runs the rules over ts and sets the status of the offending points
+// in place (a point keeps its status if it is already worse), annotating
+// each of them with the rule and its parameters (see Annotate). It returns
+// one issue per rejected point and rule, in the order the rules run:
+// timestamps, range, spike, rate of change, flatline. Each rule only judges
+// the points still valid (finite Meas, StOK) after the previous ones, so a
+// bad point cannot make its neighbors look bad too. The timestamp rule
+// expects the series in acquisition order; the other rules rely on it
+// having removed any disorder.
+//
+// It returns ErrBounds, and changes nothing, if a rule is inconsistent
+// (e.g. Min > Max or a negative duration).
+func (q QualityRules) Apply(ts *TimeSeries) ([]QualityIssue, error) {
+	if err := q.validate(); err != nil {
+		return nil, err
+	}
+	var issues []QualityIssue
+	now := time.Now()
+	mark := func(i int, reason QualityReason, st StatusCode) {
+		du := &ts.DataSeries[i]
+		du.Status = worstStatus(du.Status, st)
+		issues = append(issues, QualityIssue{Index: i, Chron: du.Chron, Reason: reason, Status: st})
+		ts.Annotate(du.Chron, Annotation{Source: "quality", Reason: string(reason), Status: st, Params: q.params(reason), At: now})
+	}
+	ds := ts.DataSeries
+
+	if q.Timestamps {
+		var latest time.Time
+		for i, d := range ds {
+			switch {
+			case i > 0 && d.Chron.Equal(latest):
+				mark(i, ReasonDuplicate, StInvalid)
+			case i > 0 && d.Chron.Before(latest):
+				mark(i, ReasonRegression, StInvalid)
+			default:
+				latest = d.Chron
+			}
+		}
+	}
+
+	if r := q.Range; r != nil {
+		for i, d := range ds {
+			if usable(d, false) && (d.Meas < r.Min || d.Meas > r.Max) {
+				mark(i, ReasonRange, StInvalid)
+			}
+		}
+	}
+
+	if s := q.Spike; s != nil {
+		_, valid := validValues(ts)
+		for k := 1; k+1 < len(valid); k++ {
+			prev, cur, next := ds[valid[k-1]], ds[valid[k]], ds[valid[k+1]]
+			if s.MaxGap > 0 && (cur.Chron.Sub(prev.Chron) > time.Duration(s.MaxGap) ||
+				next.Chron.Sub(cur.Chron) > time.Duration(s.MaxGap)) {
+				continue
+			}
+			height := math.Abs(cur.Meas-(prev.Meas+next.Meas)/2) - math.Abs(next.Meas-prev.Meas)/2
+			if height > s.Threshold {
+				mark(valid[k], ReasonSpike, StOutlier)
+			}
+		}
+	}
+
+	if r := q.RateOfChange; r != nil {
+		per := time.Duration(r.Per)
+		if per == 0 {
+			per = time.Second
+		}
+		_, valid := validValues(ts)
+		last := -1
+		for _, i := range valid {
+			if last >= 0 {
+				dt := ds[i].Chron.Sub(ds[last].Chron).Seconds() / per.Seconds()
+				if math.Abs(ds[i].Meas-ds[last].Meas) > r.MaxDelta*dt {
+					mark(i, ReasonRate, StOutlier)
+					continue
+				}
+			}
+			last = i
+		}
+	}
+
+	if f := q.Flatline; f != nil {
+		_, valid := validValues(ts)
+		start, next := 0, 0 // next: first point of the run not yet marked
+		for k, i := range valid {
+			if math.Abs(ds[i].Meas-ds[valid[start]].Meas) > f.Tolerance {
+				start, next = k, k
+				continue
+			}
+			if ds[i].Chron.Sub(ds[valid[start]].Chron) > time.Duration(f.MaxDuration) {
+				// The whole run is stuck, from its first point on.
+				for ; next <= k; next++ {
+					mark(valid[next], ReasonFlatline, StInvalid)
+				}
+			}
+		}
+	}
+	return issues, nil
+}
+
+// params returns the parameters of the rule behind reason, for annotations.
+func (q QualityRules) params(reason QualityReason) map[string]string {
+	switch reason {
+	case ReasonRange:
+		return map[string]string{"min": formatParam(q.Range.Min), "max": formatParam(q.Range.Max)}
+	case ReasonRate:
+		per := time.Duration(q.RateOfChange.Per)
+		if per == 0 {
+			per = time.Second
+		}
+		return map[string]string{"max_delta": formatParam(q.RateOfChange.MaxDelta), "per": per.String()}
+	case ReasonFlatline:
+		return map[string]string{"max_duration": time.Duration(q.Flatline.MaxDuration).String(), "tolerance": formatParam(q.Flatline.Tolerance)}
+	case ReasonSpike:
+		return map[string]string{"threshold": formatParam(q.Spike.Threshold), "max_gap": time.Duration(q.Spike.MaxGap).String()}
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package timeseries
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadQualityRules(t *testing.T) {
+	src := `{
+	  "range":          {"min": -40, "max": 125},
+	  "rate_of_change": {"max_delta": 5, "per": "1m"},
+	  "flatline":       {"max_duration": "2h", "tolerance": 0.01},
+	  "spike":          {"threshold": 10, "max_gap": 600},
+	  "timestamps":     true
+	}`
+	q, err := LoadQualityRules(strings.NewReader(src))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if q.Range.Max != 125 || time.Duration(q.RateOfChange.Per) != time.Minute ||
+		time.Duration(q.Flatline.MaxDuration) != 2*time.Hour || time.Duration(q.Spike.MaxGap) != 10*time.Minute || !q.Timestamps {
+		t.Fatalf("unexpected rules %+v", q)
+	}
+	b, _ := json.Marshal(q.Flatline)
+	if string(b) != `{"max_duration":"2h0m0s","tolerance":0.01}` {
+		t.Fatalf("marshalled %s", b)
+	}
+	if _, err := LoadQualityRules(strings.NewReader(`{"rnage": {}}`)); err == nil {
+		t.Fatal("misspelled rule must be rejected")
+	}
+	if _, err := LoadQualityRules(strings.NewReader(`{"range": {"min": 3, "max": 1}}`)); err != ErrBounds {
+		t.Fatalf("got %v, want ErrBounds", err)
+	}
+}
+
+func TestQualityRules_RangeSpikeRate(t *testing.T) {
+	ts := mkTS(20, 21, 60, 21, 22, 500, 23, 40, 41, 42)
+	q := QualityRules{
+		Range:        &RangeRule{Min: -40, Max: 125},
+		Spike:        &SpikeRule{Threshold: 10},
+		RateOfChange: &RateRule{MaxDelta: 5, Per: Duration(time.Minute)},
+	}
+	issues, err := q.Apply(&ts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []struct {
+		idx    int
+		reason QualityReason
+	}{{5, ReasonRange}, {2, ReasonSpike}, {7, ReasonRate}, {8, ReasonRate}, {9, ReasonRate}}
+	if len(issues) != len(want) {
+		t.Fatalf("got %+v", issues)
+	}
+	for k, w := range want {
+		if issues[k].Index != w.idx || issues[k].Reason != w.reason {
+			t.Fatalf("issue %d: got %+v, want %+v", k, issues[k], w)
+		}
+	}
+	if ts.DataSeries[5].Status != StInvalid || ts.DataSeries[2].Status != StOutlier || ts.DataSeries[6].Status != StOK {
+		t.Fatalf("statuses %v %v %v", ts.DataSeries[5].Status, ts.DataSeries[2].Status, ts.DataSeries[6].Status)
+	}
+}
+
+func TestQualityRules_FlatlineAndTimestamps(t *testing.T) {
+	ts := mkTS(5, 7, 7, 7.001, 7, 7, 8)
+	ts.DataSeries = append(ts.DataSeries, ts.DataSeries[6], ts.DataSeries[1])
+	q := QualityRules{Flatline: &FlatlineRule{MaxDuration: Duration(2 * time.Minute), Tolerance: 0.01}, Timestamps: true}
+	issues, err := q.Apply(&ts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []QualityReason{ReasonDuplicate, ReasonRegression,
+		ReasonFlatline, ReasonFlatline, ReasonFlatline, ReasonFlatline, ReasonFlatline}
+	wantIdx := []int{7, 8, 1, 2, 3, 4, 5}
+	if len(issues) != len(want) {
+		t.Fatalf("got %+v", issues)
+	}
+	for k := range want {
+		if issues[k].Reason != want[k] || issues[k].Index != wantIdx[k] || issues[k].Status != StInvalid {
+			t.Fatalf("issue %d: got %+v", k, issues[k])
+		}
+	}
+	if ts.DataSeries[1].Status != StInvalid || ts.DataSeries[0].Status != StOK || ts.DataSeries[6].Status != StOK {
+		t.Fatalf("the stuck run must be flagged from its first point only: %+v", ts.DataSeries[:7])
+	}
+}
@@ -1,12 +1,50 @@
 package timeseries
 
 import (
-	"log"
 	"math"
 	"time"
 )
 
-// Regularize returns a new time series sampled on a fixed interval grid
+// RegularizeOpts configures RegularizeWith.
+//
+// Fields:
+//   - Period:    width of each bucket; must be > 0.
+//   - Anchor:    phase of the grid. The zero value means the Unix epoch.
+//   - Agg:       how samples inside a bucket are condensed.
+//   - Tolerance: samples stamped up to Tolerance after a bucket boundary
+//     are still attributed to the bucket that just closed (late sensors).
+//     Must satisfy 0 <= Tolerance < Period.
+//   - Label:     stamp buckets with their start or end boundary.
+//   - Fill:      post-processing of buckets left without samples.
+//   - FillValue: the constant used by FillConstant.
+//   - MaxGap:    gaps spanning more than MaxGap stay missing (0: no limit).
+//     See FillGaps for the exact definition.
+//   - Calendar:  when not CalNone, buckets follow the civil calendar of
+//     Location instead of the Period/Anchor grid (both are then ignored).
+//   - Location:  time zone used by calendar buckets; nil means UTC.
+//   - KeepOutliers: let StOutlier samples contribute to the aggregation.
+//   - MinCount:  minimum number of usable samples for a bucket to be valid.
+//   - MinCoverage: minimum ratio of usable samples to all samples of the
+//     bucket (0..1). Buckets below MinCount or MinCoverage become StMissing
+//     (and are then subject to Fill).
+type RegularizeOpts struct {
+	Period    time.Duration
+	Anchor    time.Time
+	Agg       Agg
+	Tolerance time.Duration
+	Label     LeftOrRight
+	Fill      FillPolicy
+	FillValue float64
+	MaxGap    time.Duration
+	Calendar  CalendarPeriod
+	Location  *time.Location
+
+	KeepOutliers bool
+	MinCount     int
+	MinCoverage  float64
+}
+
+// RegularizeWith returns a new time series sampled on a fixed interval grid
 // defined by period and anchor. Input samples that fall inside each bucket
 // are condensed according to agg (e.g., AggMean, AggSum, AggLast). Buckets
 // with no valid samples are then post-processed using fill.
@@ -15,18 +53,344 @@ import (
 // anchor + k*period (for integer k). Typical choices: the start-of-series,
 // midnight in a specific location, or Unix epoch aligned to period.
 //
+// With opts.Calendar set, bucket boundaries are local calendar boundaries
+// (midnight, Monday, first of month...) computed in opts.Location, so daily
+// buckets remain one per local day across DST transitions.
+//
 // Rules:
-//   - Requires strictly increasing Chron (no duplicates). See Align/Dedup.
-//   - Only points with Status=StOK contribute to aggregation.
-//   - Outliers (StOutlier) are included unless removed upstream.
-//   - Missing/invalid points are ignored by the aggregator.
-//   - The resulting series is strictly regular and labeled with bucket end
-//     (or start) timestamps depending on implementation details.
+//   - Requires strictly increasing Chron (no duplicates).
+//   - Only points with Status=StOK and a finite Meas contribute to
+//     aggregation; outliers (StOutlier) are included only with KeepOutliers.
+//   - Missing/invalid points are ignored by the aggregator; a bucket without
+//     usable samples is StMissing.
+//   - Buckets are half-open [start, start+period) and span from the bucket
+//     of the first sample to the bucket of the last one.
+//   - The resulting series is strictly regular and labeled with bucket start
+//     or end timestamps depending on opts.Label.
+//   - The receiver is not modified.
 //
 // Errors:
-//   - ErrZeroPeriod if period <= 0.
+//   - ErrZeroPeriod if period <= 0 (fixed grids only).
 //   - ErrUnsorted if input is not strictly increasing.
-//   - ErrAnchorOutOfRange in rare, extreme-domain cases.
+//   - ErrAnchorOutOfRange when the anchor is too far from the data for the
+//     offset to be represented as a time.Duration (about 292 years).
+//   - ErrBounds on an invalid tolerance, aggregation, fill policy or
+//     coverage threshold.
+func (ts *TimeSeries) RegularizeWith(opts RegularizeOpts) (TimeSeries, error) {
+	out, _, err := ts.RegularizeWithCoverage(opts)
+	return out, err
+}
+
+// Coverage reports, for one output bucket of RegularizeWithCoverage, how
+// many input samples fell into it (Total) and how many of them were usable
+// by the aggregator (Valid). Ratio is Valid/Total, or 0 for an empty bucket.
+type Coverage struct {
+	Chron time.Time
+	Valid int
+	Total int
+	Ratio float64
+}
+
+// RegularizeWithCoverage behaves like RegularizeWith and additionally
+// returns the per-bucket Coverage, aligned index by index with the output
+// series. Buckets failing opts.MinCount or opts.MinCoverage are reported
+// with the counts that disqualified them.
+func (ts *TimeSeries) RegularizeWithCoverage(opts RegularizeOpts) (TimeSeries, []Coverage, error) {
+	out := TimeSeries{Name: ts.Name}
+	g, err := opts.grid()
+	if err != nil {
+		return out, nil, err
+	}
+	if opts.Agg < AggMin || opts.Agg > AggTrapezoid {
+		return out, nil, ErrBounds
+	}
+	if opts.Fill < FillNone || opts.Fill > FillConstant || opts.MaxGap < 0 {
+		return out, nil, ErrBounds
+	}
+	if opts.MinCount < 0 || opts.MinCoverage < 0 || opts.MinCoverage > 1 {
+		return out, nil, ErrBounds
+	}
+	n := len(ts.DataSeries)
+	if n == 0 {
+		return out, nil, nil
+	}
+	if !ts.strictlyIncreasing() {
+		return out, nil, ErrUnsorted
+	}
+
+	first, err := g.floor(ts.DataSeries[0].Chron.Add(-opts.Tolerance))
+	if err != nil {
+		return out, nil, err
+	}
+	last, err := g.floor(ts.DataSeries[n-1].Chron.Add(-opts.Tolerance))
+	if err != nil {
+		return out, nil, err
+	}
+
+	// Only usable samples are aggregated; the raw series is still walked to
+	// count the samples each bucket received.
+	valid := make([]DataUnit, 0, n)
+	for _, d := range ts.DataSeries {
+		if usable(d, opts.KeepOutliers) {
+			valid = append(valid, d)
+		}
+	}
+
+	var cov []Coverage
+	i, vi := 0, 0
+	for start := first; !start.After(last); start = g.next(start) {
+		end := g.next(start)
+		lo, vlo := i, vi
+		for i < n && ts.DataSeries[i].Chron.Add(-opts.Tolerance).Before(end) {
+			i++
+		}
+		for vi < len(valid) && valid[vi].Chron.Add(-opts.Tolerance).Before(end) {
+			vi++
+		}
+		du := DataUnit{Chron: start}
+		if opts.Label == LabelRight {
+			du.Chron = end
+		}
+		c := Coverage{Chron: du.Chron, Valid: vi - vlo, Total: i - lo}
+		if c.Total > 0 {
+			c.Ratio = float64(c.Valid) / float64(c.Total)
+		}
+		cov = append(cov, c)
+
+		ok := c.Valid >= opts.MinCount && c.Ratio >= opts.MinCoverage
+		if ok {
+			switch opts.Agg {
+			case AggTimeWeighted, AggTrapezoid:
+				// Boundaries of the bucket as seen by the samples.
+				from, to := start.Add(opts.Tolerance), end.Add(opts.Tolerance)
+				du.Meas, ok = timeWeighted(valid, vlo, vi, from, to, opts.Agg == AggTrapezoid)
+			default:
+				if ok = vi > vlo; ok {
+					du.Meas = aggregate(opts.Agg, valid[vlo:vi])
+				}
+			}
+		}
+		if !ok {
+			du.Meas = math.NaN()
+			du.Status = StMissing
+		}
+		out.AddDataUnit(du)
+	}
+	if _, err := out.FillGaps(opts.Fill, opts.FillValue, opts.MaxGap); err != nil {
+		return out, cov, err
+	}
+	return out, cov, nil
+}
+
+// usable reports whether an observation may feed an aggregator: a finite
+// Meas with Status=StOK, or StOutlier when keepOutliers is set.
+func usable(d DataUnit, keepOutliers bool) bool {
+	if math.IsNaN(d.Meas) || math.IsInf(d.Meas, 0) {
+		return false
+	}
+	return d.Status == StOK || (keepOutliers && d.Status == StOutlier)
+}
+
+// bucketGrid abstracts how bucket boundaries are laid out on the time axis.
+type bucketGrid interface {
+	// floor returns the start of the bucket containing t.
+	floor(t time.Time) (time.Time, error)
+	// next returns the start of the bucket following the one starting at start.
+	next(start time.Time) time.Time
+}
+
+// grid validates the period-related options and builds the bucket grid.
+func (opts RegularizeOpts) grid() (bucketGrid, error) {
+	if opts.Calendar != CalNone {
+		shortest, ok := calendarShortest[opts.Calendar]
+		if !ok {
+			return nil, ErrBounds
+		}
+		if opts.Tolerance < 0 || opts.Tolerance >= shortest {
+			return nil, ErrBounds
+		}
+		loc := opts.Location
+		if loc == nil {
+			loc = time.UTC
+		}
+		return calendarGrid{period: opts.Calendar, loc: loc}, nil
+	}
+	if opts.Period <= 0 {
+		return nil, ErrZeroPeriod
+	}
+	if opts.Tolerance < 0 || opts.Tolerance >= opts.Period {
+		return nil, ErrBounds
+	}
+	anchor := opts.Anchor
+	if anchor.IsZero() {
+		anchor = time.Unix(0, 0).UTC()
+	}
+	return fixedGrid{period: opts.Period, anchor: anchor}, nil
+}
+
+// fixedGrid is a regular bucket grid anchored at anchor + k*period.
+type fixedGrid struct {
+	period time.Duration
+	anchor time.Time
+}
+
+func (g fixedGrid) floor(t time.Time) (time.Time, error) {
+	d := t.Sub(g.anchor)
+	if d == math.MaxInt64 || d == math.MinInt64 {
+		// time.Time.Sub saturates instead of overflowing.
+		return time.Time{}, ErrAnchorOutOfRange
+	}
+	k := d / g.period
+	if d%g.period < 0 {
+		k--
+	}
+	return g.anchor.Add(k * g.period), nil
+}
+
+func (g fixedGrid) next(start time.Time) time.Time {
+	return start.Add(g.period)
+}
+
+// calendarShortest is the shortest possible length of each calendar bucket,
+// accounting for a one hour DST shift. It bounds the admissible tolerance.
+var calendarShortest = map[CalendarPeriod]time.Duration{
+	CalDay:     23 * time.Hour,
+	CalWeek:    7*24*time.Hour - time.Hour,
+	CalMonth:   28*24*time.Hour - time.Hour,
+	CalQuarter: 90*24*time.Hour - time.Hour,
+	CalYear:    365*24*time.Hour - time.Hour,
+}
+
+// calendarGrid lays buckets on civil calendar boundaries in loc.
+type calendarGrid struct {
+	period CalendarPeriod
+	loc    *time.Location
+}
+
+func (g calendarGrid) floor(t time.Time) (time.Time, error) {
+	t = t.In(g.loc)
+	y, m, d := t.Date()
+	switch g.period {
+	case CalWeek:
+		// ISO weeks start on Monday.
+		d -= (int(t.Weekday()) + 6) % 7
+	case CalMonth:
+		d = 1
+	case CalQuarter:
+		m = (m-1)/3*3 + 1
+		d = 1
+	case CalYear:
+		m, d = time.January, 1
+	}
+	return time.Date(y, m, d, 0, 0, 0, 0, g.loc), nil
+}
+
+func (g calendarGrid) next(start time.Time) time.Time {
+	y, m, d := start.In(g.loc).Date()
+	switch g.period {
+	case CalDay:
+		d++
+	case CalWeek:
+		d += 7
+	case CalMonth:
+		m++
+	case CalQuarter:
+		m += 3
+	case CalYear:
+		y++
+	}
+	return time.Date(y, m, d, 0, 0, 0, 0, g.loc)
+}
+
+// aggregate condenses the samples of one bucket according to agg.
+// samples must not be empty.
+func aggregate(agg Agg, samples []DataUnit) float64 {
+	switch agg {
+	case AggMin:
+		v := samples[0].Meas
+		for _, s := range samples[1:] {
+			if s.Meas < v {
+				v = s.Meas
+			}
+		}
+		return v
+	case AggMax:
+		v := samples[0].Meas
+		for _, s := range samples[1:] {
+			if s.Meas > v {
+				v = s.Meas
+			}
+		}
+		return v
+	case AggLast:
+		return samples[len(samples)-1].Meas
+	case AggSum, AggMean:
+		sum := 0.0
+		for _, s := range samples {
+			sum += s.Meas
+		}
+		if agg == AggMean {
+			return sum / float64(len(samples))
+		}
+		return sum
+	}
+	return math.NaN()
+}
+
+// timeWeighted returns the time-weighted mean over [from, to) of the signal
+// described by samples, where samples[lo:hi] are the samples inside the
+// bucket. The signal is step-hold (each sample holds until the next one) or,
+// with trapezoid, linear between consecutive samples. The signal is only
+// defined between the first and the last sample: nothing is extrapolated.
+// When the bucket has no measurable extent (e.g. a lone sample) the plain
+// mean of its samples is returned. ok is false if no value can be derived.
+func timeWeighted(samples []DataUnit, lo, hi int, from, to time.Time, trapezoid bool) (v float64, ok bool) {
+	var area, total float64
+	// Segment k joins samples k and k+1; from the one straddling from
+	// (lo-1) to the one leaving the bucket (hi-1).
+	for k := lo - 1; k < hi; k++ {
+		if k < 0 || k+1 >= len(samples) {
+			continue
+		}
+		a, b := samples[k], samples[k+1]
+		sa, sb := a.Chron, b.Chron
+		if sa.Before(from) {
+			sa = from
+		}
+		if sb.After(to) {
+			sb = to
+		}
+		length := float64(sb.Sub(sa))
+		if length <= 0 {
+			continue
+		}
+		if trapezoid {
+			span := float64(b.Chron.Sub(a.Chron))
+			va := a.Meas + (b.Meas-a.Meas)*float64(sa.Sub(a.Chron))/span
+			vb := a.Meas + (b.Meas-a.Meas)*float64(sb.Sub(a.Chron))/span
+			area += (va + vb) / 2 * length
+		} else {
+			area += a.Meas * length
+		}
+		total += length
+	}
+	if total > 0 {
+		return area / total, true
+	}
+	if hi > lo {
+		return aggregate(AggMean, samples[lo:hi]), true
+	}
+	return math.NaN(), false
+}
+
+// Regularize is the original string-driven regularization: freq and per
+// ("s", "m", "h" and their long forms) give the bucket width, meth the
+// aggregation ("avg", "max", "min", "last", "sum"). Buckets are closed on the
+// right and labeled with their end; the receiver is sorted in place. Only
+// StOK points with a finite Meas are aggregated.
+//
+// An unknown period yields an empty series. New code should prefer
+// RegularizeWith, which reports errors instead.
 func (ts *TimeSeries) Regularize(freq int, per string, meth string, tolerance int) TimeSeries {
 	// normalisation de l’unité
 	switch per {
@@ -37,7 +401,7 @@ func (ts *TimeSeries) Regularize(freq int, per string, meth string, tolerance in
 	case "Hours", "h":
 		per = "h"
 	default:
-		log.Fatal("period not accepted")
+		return TimeSeries{}
 	}
 
 	var out TimeSeries
@@ -66,13 +430,21 @@ func (ts *TimeSeries) Regularize(freq int, per string, meth string, tolerance in
 		var local []float64
 		local = nil
 
+		seen := 0
 		for i < len(ts.DataSeries) && !ts.DataSeries[i].Chron.After(windowEnd) {
-			sum += ts.DataSeries[i].Meas
-			local = append(local, ts.DataSeries[i].Meas)
+			// seuls les points StOK non NaN contribuent
+			if usable(ts.DataSeries[i], false) {
+				sum += ts.DataSeries[i].Meas
+				local = append(local, ts.DataSeries[i].Meas)
+			}
+			seen++
 			i++
 		}
 
 		// 2) Sortie pour la fenêtre courante
+		if len(local) == 0 && seen > 0 {
+			out.AddDataUnit(DataUnit{Chron: windowEnd, Meas: math.NaN(), Status: StMissing})
+		}
 		if len(local) > 0 {
 			var du DataUnit
 			du.Chron = windowEnd
@@ -114,7 +486,7 @@ func (ts *TimeSeries) Regularize(freq int, per string, meth string, tolerance in
 			}
 
 			// sinon, la fenêtre est vide -> NaN
-			du := DataUnit{Chron: nextEnd, Meas: math.NaN()}
+			du := DataUnit{Chron: nextEnd, Meas: math.NaN(), Status: StMissing}
 			out.AddDataUnit(du)
 
 			// avancer encore d'une fenêtre et re-tester
@@ -126,6 +498,8 @@ func (ts *TimeSeries) Regularize(freq int, per string, meth string, tolerance in
 }
 
 // Truncate a datetime to the closest beginning of time frequence but below. Ancillary to resampling methods.
+// Calendar periods ("d", "w", "M", "y") truncate to local midnight, the ISO week's Monday, the first of the month or
+// January 1st in the location of timetoround, whatever afreqq; DST days are handled as in RegularizeWith.
 func RoundedStartTime(timetoround time.Time, afreqq int, aper string) time.Time {
 	roundedtime := time.Now()
 	switch aper {
@@ -136,17 +510,31 @@ func RoundedStartTime(timetoround time.Time, afreqq int, aper string) time.Time
 	case "h":
 		roundedtime = timetoround.Truncate(time.Hour * time.Duration(afreqq))
 	case "d":
-		roundedtime = timetoround.AddDate(0, 0, -afreqq)
+		roundedtime, _ = calendarGrid{period: CalDay, loc: timetoround.Location()}.floor(timetoround)
+	case "w":
+		roundedtime, _ = calendarGrid{period: CalWeek, loc: timetoround.Location()}.floor(timetoround)
+	case "M":
+		roundedtime, _ = calendarGrid{period: CalMonth, loc: timetoround.Location()}.floor(timetoround)
+	case "y":
+		roundedtime, _ = calendarGrid{period: CalYear, loc: timetoround.Location()}.floor(timetoround)
 	default:
 		roundedtime = timetoround
 	}
 	return roundedtime
 }
 
-// Add duration to a given date. The parameter is a string consisting of an integer and one letter ("s" for seconds, "m" for minute, "h" for hour).
-// same function than time.Add.
+// Add duration to a given date. The parameter is a string consisting of an integer and one letter ("s" for seconds, "m" for minute, "h" for hour,
+// "d" for day, "w" for week, "M" for month, "y" for year). Days and longer are calendar steps in the location of start (see time.AddDate).
 func AddDuration(start time.Time, freq int, per string) time.Time {
 	switch per {
+	case "d":
+		return start.AddDate(0, 0, freq)
+	case "w":
+		return start.AddDate(0, 0, 7*freq)
+	case "M":
+		return start.AddDate(0, freq, 0)
+	case "y":
+		return start.AddDate(freq, 0, 0)
 	case "s":
 		return start.Add(time.Second * time.Duration(freq))
 	case "m":
@@ -161,9 +549,14 @@ func AddDuration(start time.Time, freq int, per string) time.Time {
 // AddDurationTol add a duration plus a tolerance. Tolerance is an int. If the period is in seconds, tolerance is expressed
 // in Millisecond. If the period is in Minutes, the tolerance is expressed in Seconds. If the period is in Hours, tolerance
 // is expressed in Minutes. So if the regularisation is 30 minutes with a 3 minutes tolerance, 3 minutes should be expressed
-// as 180
+// as 180. For calendar periods the step is taken as in AddDuration, and tolerance is expressed in Hours for days and
+// in days for weeks, months and years.
 func AddDurationTol(start time.Time, freq int, per string, tolerance int) time.Time {
 	switch per {
+	case "d":
+		return AddDuration(start, freq, per).Add(time.Hour * time.Duration(tolerance))
+	case "w", "M", "y":
+		return AddDuration(start, freq, per).AddDate(0, 0, tolerance)
 	case "s":
 		return start.Add(time.Millisecond * time.Duration(freq*1000+tolerance))
 	case "m":
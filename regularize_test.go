@@ -64,10 +64,25 @@ func TestRoundedStartTime(t *testing.T) {
 	if got := RoundedStartTime(base, 2, "h"); !got.Equal(mustTime(2025, 11, 10, 10, 0, 0)) {
 		t.Fatalf("hours: got %v", got)
 	}
-	// "d" path: subtract afreqq days
-	if got := RoundedStartTime(base, 1, "d"); !got.Equal(mustTime(2025, 11, 9, 10, 23, 45)) {
+	// Calendar paths truncate to local boundaries.
+	if got := RoundedStartTime(base, 1, "d"); !got.Equal(mustTime(2025, 11, 10, 0, 0, 0)) {
 		t.Fatalf("days: got %v", got)
 	}
+	if got := RoundedStartTime(base, 1, "w"); !got.Equal(mustTime(2025, 11, 10, 0, 0, 0)) {
+		t.Fatalf("weeks: got %v", got)
+	}
+	if got := RoundedStartTime(base, 1, "M"); !got.Equal(mustTime(2025, 11, 1, 0, 0, 0)) {
+		t.Fatalf("months: got %v", got)
+	}
+	paris, err := time.LoadLocation("Europe/Paris")
+	if err != nil {
+		t.Skip("no tzdata")
+	}
+	// The evening of a 23-hour DST day stays on that local day.
+	local := time.Date(2025, 3, 30, 23, 30, 0, 0, paris)
+	if got := RoundedStartTime(local, 1, "d"); !got.Equal(time.Date(2025, 3, 30, 0, 0, 0, 0, paris)) {
+		t.Fatalf("local day: got %v", got)
+	}
 }
 
 // --------- AddDuration & AddDurationTol (tol=0 path) ---------
@@ -97,6 +112,17 @@ func TestAddDurationTolZero(t *testing.T) {
 	if got := AddDurationTol(start, 3, "h", 0); !got.Equal(AddDuration(start, 3, "h")) {
 		t.Fatalf("hour tol=0 mismatch: got %v", got)
 	}
+	for _, per := range []string{"d", "w", "M", "y"} {
+		if got := AddDurationTol(start, 2, per, 0); !got.Equal(AddDuration(start, 2, per)) {
+			t.Fatalf("%s tol=0 mismatch: got %v", per, got)
+		}
+	}
+	if got := AddDurationTol(start, 1, "d", 2); !got.Equal(mustTime(2025, 11, 11, 14, 0, 0)) {
+		t.Fatalf("day tolerance in hours: got %v", got)
+	}
+	if got := AddDurationTol(start, 1, "M", 3); !got.Equal(mustTime(2025, 12, 13, 12, 0, 0)) {
+		t.Fatalf("month tolerance in days: got %v", got)
+	}
 }
 
 // --------- HourlyAvg ---------
@@ -334,3 +360,343 @@ func TestRegularize_LeadingEmptyWindows_InsertNaNs(t *testing.T) {
 
 	requireSeriesEq(t, got, want, 1e-12)
 }
+
+// --------- RegularizeWith ---------
+
+func TestRegularizeWith_MeanLabelLeftAndRight(t *testing.T) {
+	ts := buildSimpleSeries()
+	base := mustTime(2025, 11, 10, 10, 0, 0)
+
+	got, err := ts.RegularizeWith(RegularizeOpts{Period: 30 * time.Second, Agg: AggMean})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := TimeSeries{}
+	want.AddDataUnit(
+		du(base, 2),                      // [10:00:00,10:00:30) -> (1+3)/2
+		du(base.Add(30*time.Second), 10), // [10:00:30,10:01:00) -> 10
+	)
+	requireSeriesEq(t, got, want, 1e-12)
+
+	got, err = ts.RegularizeWith(RegularizeOpts{Period: 30 * time.Second, Agg: AggMean, Label: LabelRight})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want = TimeSeries{}
+	want.AddDataUnit(
+		du(base.Add(30*time.Second), 2),
+		du(base.Add(60*time.Second), 10),
+	)
+	requireSeriesEq(t, got, want, 1e-12)
+}
+
+func TestRegularizeWith_GapsAreMissing(t *testing.T) {
+	base := mustTime(2025, 11, 10, 10, 0, 0)
+	ts := TimeSeries{}
+	ts.AddDataUnit(
+		du(base.Add(5*time.Second), 1),
+		du(base.Add(95*time.Second), 4),
+	)
+	got, err := ts.RegularizeWith(RegularizeOpts{Period: 30 * time.Second, Agg: AggSum})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := TimeSeries{}
+	want.AddDataUnit(
+		du(base, 1),
+		du(base.Add(30*time.Second), math.NaN()),
+		du(base.Add(60*time.Second), math.NaN()),
+		du(base.Add(90*time.Second), 4),
+	)
+	requireSeriesEq(t, got, want, 0)
+	for _, i := range []int{1, 2} {
+		if got.DataSeries[i].Status != StMissing {
+			t.Fatalf("bucket %d: status %v, want StMissing", i, got.DataSeries[i].Status)
+		}
+	}
+}
+
+func TestRegularizeWith_AnchorAndTolerance(t *testing.T) {
+	base := mustTime(2025, 11, 10, 10, 0, 0)
+	ts := TimeSeries{}
+	ts.AddDataUnit(
+		du(base.Add(14*time.Minute), 1),
+		du(base.Add(15*time.Minute+2*time.Second), 2), // late reading of the first bucket
+		du(base.Add(29*time.Minute), 3),
+	)
+	got, err := ts.RegularizeWith(RegularizeOpts{
+		Period:    15 * time.Minute,
+		Anchor:    base,
+		Agg:       AggLast,
+		Tolerance: 5 * time.Second,
+		Label:     LabelRight,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := TimeSeries{}
+	want.AddDataUnit(
+		du(base.Add(15*time.Minute), 2),
+		du(base.Add(30*time.Minute), 3),
+	)
+	requireSeriesEq(t, got, want, 0)
+
+	// A shifted anchor shifts the grid phase.
+	got, err = ts.RegularizeWith(RegularizeOpts{Period: 15 * time.Minute, Anchor: base.Add(5 * time.Minute), Agg: AggLast})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !got.DataSeries[0].Chron.Equal(base.Add(5 * time.Minute)) {
+		t.Fatalf("first bucket: got %v, want %v", got.DataSeries[0].Chron, base.Add(5*time.Minute))
+	}
+}
+
+func TestRegularizeWith_Errors(t *testing.T) {
+	ts := buildSimpleSeries()
+	if _, err := ts.RegularizeWith(RegularizeOpts{}); err != ErrZeroPeriod {
+		t.Fatalf("zero period: got %v, want ErrZeroPeriod", err)
+	}
+	if _, err := ts.RegularizeWith(RegularizeOpts{Period: time.Second, Tolerance: time.Second}); err != ErrBounds {
+		t.Fatalf("tolerance >= period: got %v, want ErrBounds", err)
+	}
+	if _, err := ts.RegularizeWith(RegularizeOpts{Period: time.Second, Agg: Agg(42)}); err != ErrBounds {
+		t.Fatalf("unknown agg: got %v, want ErrBounds", err)
+	}
+	if _, err := ts.RegularizeWith(RegularizeOpts{Period: time.Second, Anchor: time.Date(1, 1, 1, 0, 0, 1, 0, time.UTC)}); err != ErrAnchorOutOfRange {
+		t.Fatalf("far anchor: got %v, want ErrAnchorOutOfRange", err)
+	}
+	unsorted := TimeSeries{}
+	unsorted.AddDataUnit(du(mustTime(2025, 1, 1, 0, 0, 10), 1), du(mustTime(2025, 1, 1, 0, 0, 5), 2))
+	if _, err := unsorted.RegularizeWith(RegularizeOpts{Period: time.Second}); err != ErrUnsorted {
+		t.Fatalf("unsorted: got %v, want ErrUnsorted", err)
+	}
+}
+
+func TestRegularize_UnknownPeriodDoesNotExit(t *testing.T) {
+	ts := buildSimpleSeries()
+	if got := ts.Regularize(1, "days", "avg", 0); len(got.DataSeries) != 0 {
+		t.Fatalf("unknown period: expected empty series, got %d points", len(got.DataSeries))
+	}
+}
+
+// --------- RegularizeWith: calendar buckets ---------
+
+func loadLocation(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Skipf("time zone %s unavailable: %v", name, err)
+	}
+	return loc
+}
+
+func TestRegularizeWith_CalendarDayAcrossDST(t *testing.T) {
+	paris := loadLocation(t, "Europe/Paris")
+	// Hourly counter of 1 from 2025-03-29 00:00 to 2025-03-31 23:00 local time.
+	// 2025-03-30 only has 23 hours in Paris (spring forward).
+	ts := TimeSeries{}
+	start := time.Date(2025, 3, 29, 0, 0, 0, 0, paris)
+	end := time.Date(2025, 4, 1, 0, 0, 0, 0, paris)
+	for c := start; c.Before(end); c = c.Add(time.Hour) {
+		ts.AddDataUnit(du(c, 1))
+	}
+	got, err := ts.RegularizeWith(RegularizeOpts{Calendar: CalDay, Location: paris, Agg: AggSum})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := TimeSeries{}
+	want.AddDataUnit(
+		du(time.Date(2025, 3, 29, 0, 0, 0, 0, paris), 24),
+		du(time.Date(2025, 3, 30, 0, 0, 0, 0, paris), 23),
+		du(time.Date(2025, 3, 31, 0, 0, 0, 0, paris), 24),
+	)
+	requireSeriesEq(t, got, want, 0)
+}
+
+func TestRegularizeWith_CalendarWeekMonthQuarterYear(t *testing.T) {
+	ts := TimeSeries{}
+	ts.AddDataUnit(
+		du(mustTime(2025, 1, 15, 12, 0, 0), 1), // Wednesday
+		du(mustTime(2025, 2, 20, 12, 0, 0), 2),
+		du(mustTime(2025, 5, 2, 12, 0, 0), 3),
+	)
+	cases := []struct {
+		cal   CalendarPeriod
+		first time.Time
+		n     int
+	}{
+		{CalWeek, mustTime(2025, 1, 13, 0, 0, 0), 16},
+		{CalMonth, mustTime(2025, 1, 1, 0, 0, 0), 5},
+		{CalQuarter, mustTime(2025, 1, 1, 0, 0, 0), 2},
+		{CalYear, mustTime(2025, 1, 1, 0, 0, 0), 1},
+	}
+	for _, c := range cases {
+		got, err := ts.RegularizeWith(RegularizeOpts{Calendar: c.cal, Agg: AggSum})
+		if err != nil {
+			t.Fatalf("calendar %d: unexpected error: %v", c.cal, err)
+		}
+		if len(got.DataSeries) != c.n {
+			t.Fatalf("calendar %d: got %d buckets, want %d", c.cal, len(got.DataSeries), c.n)
+		}
+		if !got.DataSeries[0].Chron.Equal(c.first) {
+			t.Fatalf("calendar %d: first bucket %v, want %v", c.cal, got.DataSeries[0].Chron, c.first)
+		}
+	}
+}
+
+func TestAddDuration_CalendarUnits(t *testing.T) {
+	start := mustTime(2025, 1, 31, 12, 0, 0)
+	if got := AddDuration(start, 2, "d"); !got.Equal(mustTime(2025, 2, 2, 12, 0, 0)) {
+		t.Fatalf("days: got %v", got)
+	}
+	if got := AddDuration(start, 1, "w"); !got.Equal(mustTime(2025, 2, 7, 12, 0, 0)) {
+		t.Fatalf("weeks: got %v", got)
+	}
+	if got := AddDuration(mustTime(2025, 1, 15, 0, 0, 0), 1, "M"); !got.Equal(mustTime(2025, 2, 15, 0, 0, 0)) {
+		t.Fatalf("months: got %v", got)
+	}
+	if got := AddDuration(start, 1, "y"); !got.Equal(mustTime(2026, 1, 31, 12, 0, 0)) {
+		t.Fatalf("years: got %v", got)
+	}
+}
+
+func TestRegularize_EmptyWindowsAreMissing(t *testing.T) {
+	base := mustTime(2025, 11, 10, 10, 0, 0)
+	ts := TimeSeries{}
+	ts.AddDataUnit(du(base.Add(5*time.Second), 1), du(base.Add(100*time.Second), 2))
+	got := ts.Regularize(30, "s", "last", 0)
+	for _, d := range got.DataSeries {
+		if math.IsNaN(d.Meas) && d.Status != StMissing {
+			t.Fatalf("NaN window at %v has status %v, want StMissing", d.Chron, d.Status)
+		}
+	}
+}
+
+// --------- RegularizeWith: time-weighted aggregations ---------
+
+func TestRegularizeWith_TimeWeighted(t *testing.T) {
+	base := mustTime(2025, 11, 10, 10, 0, 0)
+	ts := TimeSeries{}
+	ts.AddDataUnit(
+		du(base, 2),
+		du(base.Add(45*time.Second), 4),
+		du(base.Add(90*time.Second), 6),
+	)
+
+	got, err := ts.RegularizeWith(RegularizeOpts{Period: time.Minute, Agg: AggTimeWeighted})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := TimeSeries{}
+	want.AddDataUnit(
+		du(base, 2.5),                  // 2 for 45s, 4 for 15s
+		du(base.Add(time.Minute), 4.0), // 4 carried in for 30s; nothing after 6
+	)
+	requireSeriesEq(t, got, want, 1e-12)
+
+	got, err = ts.RegularizeWith(RegularizeOpts{Period: time.Minute, Agg: AggTrapezoid})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want = TimeSeries{}
+	want.AddDataUnit(
+		du(base, 200.0/60),                // 3*45 + (4+14/3)/2*15
+		du(base.Add(time.Minute), 16.0/3), // (14/3+6)/2
+	)
+	requireSeriesEq(t, got, want, 1e-9)
+}
+
+func TestRegularizeWith_TimeWeightedBridgesEmptyBuckets(t *testing.T) {
+	base := mustTime(2025, 11, 10, 10, 0, 0)
+	ts := TimeSeries{}
+	ts.AddDataUnit(
+		du(base, 1),
+		du(base.Add(150*time.Second), 3),
+	)
+	got, err := ts.RegularizeWith(RegularizeOpts{Period: time.Minute, Agg: AggTimeWeighted})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got.DataSeries) != 3 {
+		t.Fatalf("got %d buckets, want 3", len(got.DataSeries))
+	}
+	// The middle bucket has no sample but the value 1 holds through it.
+	if mid := got.DataSeries[1]; mid.Meas != 1 || mid.Status != StOK {
+		t.Fatalf("middle bucket: got (%v,%v), want (1,StOK)", mid.Meas, mid.Status)
+	}
+}
+
+// --------- Status-aware regularization ---------
+
+func statusSeries() TimeSeries {
+	base := mustTime(2025, 11, 10, 10, 0, 0)
+	ts := TimeSeries{}
+	ts.AddDataUnit(
+		du(base.Add(5*time.Second), 1),
+		NewDataUnitWithStatus(base.Add(10*time.Second), math.NaN(), StMissing),
+		NewDataUnitWithStatus(base.Add(15*time.Second), 1000, StInvalid),
+		du(base.Add(20*time.Second), 3),
+		NewDataUnitWithStatus(base.Add(35*time.Second), 50, StOutlier),
+		NewDataUnitWithStatus(base.Add(40*time.Second), 60, StInvalid),
+	)
+	return ts
+}
+
+func TestRegularizeWith_HonorsStatus(t *testing.T) {
+	ts := statusSeries()
+	base := mustTime(2025, 11, 10, 10, 0, 0)
+
+	got, cov, err := ts.RegularizeWithCoverage(RegularizeOpts{Period: 30 * time.Second, Agg: AggMean})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := TimeSeries{}
+	want.AddDataUnit(du(base, 2), du(base.Add(30*time.Second), math.NaN()))
+	requireSeriesEq(t, got, want, 1e-12)
+	if got.DataSeries[1].Status != StMissing {
+		t.Fatalf("bucket with only outlier/invalid points must be StMissing")
+	}
+	if cov[0].Valid != 2 || cov[0].Total != 4 || !almostEq(cov[0].Ratio, 0.5, 1e-12) {
+		t.Fatalf("coverage[0] = %+v, want 2/4", cov[0])
+	}
+	if cov[1].Valid != 0 || cov[1].Total != 2 {
+		t.Fatalf("coverage[1] = %+v, want 0/2", cov[1])
+	}
+
+	got, err = ts.RegularizeWith(RegularizeOpts{Period: 30 * time.Second, Agg: AggMean, KeepOutliers: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.DataSeries[1].Meas != 50 {
+		t.Fatalf("KeepOutliers: got %v, want 50", got.DataSeries[1].Meas)
+	}
+}
+
+func TestRegularizeWith_MinCoverage(t *testing.T) {
+	ts := statusSeries()
+	got, err := ts.RegularizeWith(RegularizeOpts{Period: 30 * time.Second, Agg: AggMean, MinCoverage: 0.6})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.DataSeries[0].Status != StMissing {
+		t.Fatalf("bucket at 50%% coverage must be StMissing with MinCoverage=0.6")
+	}
+	got, err = ts.RegularizeWith(RegularizeOpts{Period: 30 * time.Second, Agg: AggMean, MinCount: 3})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.DataSeries[0].Status != StMissing {
+		t.Fatalf("bucket with 2 valid points must be StMissing with MinCount=3")
+	}
+	if _, err := ts.RegularizeWith(RegularizeOpts{Period: time.Second, MinCoverage: 1.5}); err != ErrBounds {
+		t.Fatalf("MinCoverage > 1: got %v, want ErrBounds", err)
+	}
+}
+
+func TestRegularize_SkipsNonValid(t *testing.T) {
+	ts := statusSeries()
+	got := ts.Regularize(30, "s", "avg", 0)
+	if !almostEq(got.DataSeries[0].Meas, 2, 1e-12) {
+		t.Fatalf("first window: got %v, want 2", got.DataSeries[0].Meas)
+	}
+}
@@ -0,0 +1,127 @@
+package timeseries
+
+import (
+	"math"
+	"time"
+)
+
+// madScale turns a MAD into a consistent estimator of the standard
+// deviation of normal data (1/Φ⁻¹(3/4)).
+const madScale = 1.4826
+
+// HampelCleaning removes outliers with a Hampel filter: a valid point is an
+// outlier when it deviates from the median of its centered time window by
+// more than k scaled MADs of that window,
+//
+//	|x - median| > k * 1.4826 * MAD
+//
+// Windows are those of Rolling with Center set: (t-window/2, t+window/2],
+// valid points only. Unlike a z-score the envelope follows the local level
+// and is not inflated by the outliers themselves; k = 3 is customary.
+//
+// It returns (cleaned, rejected) like ZscoreCleaning: rejected holds the
+// outliers tagged StOutlier, cleaned every other point unchanged, both in
+// the receiver's order. An unsorted series or a window <= 0 rejects nothing.
+func (tsin *TimeSeries) HampelCleaning(window time.Duration, k float64) (TimeSeries, TimeSeries) {
+	return tsin.splitOutliers(tsin.hampelIndices(window, k))
+}
+
+// HampelFlag applies the Hampel filter of HampelCleaning in place: the
+// outliers get Status=StOutlier and stay in the series. It is a shorthand
+// for Flag(HampelDetector{window, k}) and returns the number of points
+// flagged.
+func (tsin *TimeSeries) HampelFlag(window time.Duration, k float64) int {
+	return len(tsin.Flag(HampelDetector{Window: window, K: k}))
+}
+
+// MADCleaning removes outliers by their modified z-score (Iglewicz and
+// Hoaglin) over the whole series,
+//
+//	z = 0.6745 * (x - median) / MAD
+//
+// and rejects the valid points with |z| > threshold; 3.5 is the usual
+// choice. When more than half of the values are equal, MAD is 0 and the
+// mean absolute deviation (scaled by 1.2533) is used instead. Only valid
+// points enter the median and are judged.
+//
+// It returns (cleaned, rejected) like ZscoreCleaning, rejected points being
+// tagged StOutlier.
+func (tsin *TimeSeries) MADCleaning(threshold float64) (TimeSeries, TimeSeries) {
+	return tsin.splitOutliers(tsin.madIndices(threshold))
+}
+
+// MADFlag applies the detector of MADCleaning in place: the outliers get
+// Status=StOutlier and stay in the series. It is a shorthand for
+// Flag(MADDetector{threshold}) and returns the number of points flagged.
+func (tsin *TimeSeries) MADFlag(threshold float64) int {
+	return len(tsin.Flag(MADDetector{Threshold: threshold}))
+}
+
+// hampelIndices returns the positions of the Hampel outliers.
+func (tsin *TimeSeries) hampelIndices(window time.Duration, k float64) []int {
+	med, err := tsin.Rolling(window, RollingOpts{Stat: RollMedian, Center: true})
+	if err != nil {
+		return nil
+	}
+	mad, _ := tsin.Rolling(window, RollingOpts{Stat: RollCustom, Center: true, Reducer: func(v []float64) float64 {
+		m, _, _ := MAD(v)
+		return m
+	}})
+	var idx []int
+	for i, d := range tsin.DataSeries {
+		if !usable(d, false) {
+			continue
+		}
+		if math.Abs(d.Meas-med.DataSeries[i].Meas) > k*madScale*mad.DataSeries[i].Meas {
+			idx = append(idx, i)
+		}
+	}
+	return idx
+}
+
+// madIndices returns the positions of the points whose modified z-score
+// exceeds threshold.
+func (tsin *TimeSeries) madIndices(threshold float64) []int {
+	var vals []float64
+	for _, d := range tsin.DataSeries {
+		if usable(d, false) {
+			vals = append(vals, d.Meas)
+		}
+	}
+	mad, med, err := MAD(vals)
+	if err != nil {
+		return nil
+	}
+	// 0.6745/MAD, or its mean absolute deviation fallback 1/(1.2533*MeanAD).
+	scale := 0.6745 / mad
+	if mad == 0 {
+		var meanAD float64
+		for _, v := range vals {
+			meanAD += math.Abs(v - med)
+		}
+		meanAD /= float64(len(vals))
+		if meanAD == 0 {
+			return nil
+		}
+		scale = 1 / (1.253314 * meanAD)
+	}
+	var idx []int
+	for i, d := range tsin.DataSeries {
+		if usable(d, false) && math.Abs(d.Meas-med)*scale > threshold {
+			idx = append(idx, i)
+		}
+	}
+	return idx
+}
+
+// splitOutliers returns (cleaned, rejected) for the outliers at idx,
+// tagging the rejected points StOutlier.
+func (tsin *TimeSeries) splitOutliers(idx []int) (TimeSeries, TimeSeries) {
+	tsout, tsrej := tsin.RemoveElements(idx...)
+	for i := range tsrej.DataSeries {
+		tsrej.DataSeries[i].Status = StOutlier
+	}
+	tsout.Name = tsin.Name + " Cleaned"
+	tsrej.Name = tsin.Name + " Removed"
+	return tsout, tsrej
+}
@@ -0,0 +1,57 @@
+package timeseries
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMAD(t *testing.T) {
+	in := []float64{1, 1, 2, 2, 4, 6, 9}
+	mad, med, err := MAD(in)
+	if err != nil || mad != 1 || med != 2 {
+		t.Fatalf("got mad=%v median=%v err=%v, want 1, 2, nil", mad, med, err)
+	}
+	if in[6] != 9 || in[4] != 4 {
+		t.Fatal("MAD must not modify its input")
+	}
+	if _, _, err := MAD(nil); err != ErrEmptyInput {
+		t.Fatalf("got %v, want ErrEmptyInput", err)
+	}
+}
+
+func TestHampel_FollowsLocalLevel(t *testing.T) {
+	// A ramp with one spike: a global z-score would miss it or hit the ends.
+	ts := mkTS(0, 1, 2, 3, 4, 30, 6, 7, 8, 9, 10, 11)
+	ts.DataSeries[2].Meas = 2.2
+	cleaned, rejected := ts.HampelCleaning(5*time.Minute, 3)
+	if len(rejected.DataSeries) != 1 || rejected.DataSeries[0].Meas != 30 {
+		t.Fatalf("rejected %+v, want the spike only", rejected.DataSeries)
+	}
+	if rejected.DataSeries[0].Status != StOutlier || len(cleaned.DataSeries) != 11 {
+		t.Fatalf("rejected status %v, cleaned size %d", rejected.DataSeries[0].Status, len(cleaned.DataSeries))
+	}
+	if !chronIsSortedAsc(cleaned) {
+		t.Fatal("cleaned series must keep chronological order")
+	}
+
+	if n := ts.HampelFlag(5*time.Minute, 3); n != 1 || ts.DataSeries[5].Status != StOutlier {
+		t.Fatalf("flagged %d, status %v", n, ts.DataSeries[5].Status)
+	}
+	if len(ts.DataSeries) != 12 {
+		t.Fatal("flagging must keep every point")
+	}
+}
+
+func TestMADCleaning_SkewedData(t *testing.T) {
+	ts := mkTS(10, 11, 10, 12, 11, 13, 10, 11, 250, 12, 11, 10)
+	_, rejected := ts.MADCleaning(3.5)
+	if len(rejected.DataSeries) != 1 || rejected.DataSeries[0].Meas != 250 {
+		t.Fatalf("rejected %+v, want 250 only", rejected.DataSeries)
+	}
+
+	// More than half of the values equal: MAD is 0, MeanAD takes over.
+	flat := mkTS(5, 5, 5, 5, 5, 5, 6, 40)
+	if n := flat.MADFlag(3.5); n != 1 || flat.DataSeries[7].Status != StOutlier {
+		t.Fatalf("flagged %d, last status %v", n, flat.DataSeries[7].Status)
+	}
+}
@@ -0,0 +1,301 @@
+package timeseries
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// RollingOpts configures Rolling and RollingN.
+//
+// Fields:
+//   - Stat:         the statistic computed over each window.
+//   - Q:            the quantile in [0, 1] used by RollQuantile.
+//   - Reducer:      the function used by RollCustom. It receives a scratch
+//     copy of the valid window values in chronological order.
+//   - Center:       center the window on each point instead of ending it
+//     there.
+//   - MinPeriods:   minimum number of valid observations a window needs to
+//     produce a value. 0 means 1, except for RollCount which always counts.
+//   - KeepOutliers: also use StOutlier points, as in RegularizeOpts.
+type RollingOpts struct {
+	Stat         RollStat
+	Q            float64
+	Reducer      func(vals []float64) float64
+	Center       bool
+	MinPeriods   int
+	KeepOutliers bool
+}
+
+// Rolling computes opts.Stat over a sliding time window and returns one
+// point per input point, at the same timestamp. Windows are defined on
+// Chron, not on positions, so irregular sampling is handled naturally:
+//   - trailing (default): the points in (t-window, t];
+//   - centered:           the points in (t-window/2, t+window/2].
+//
+// Only valid observations (finite Meas, StOK, or StOutlier with
+// KeepOutliers) enter a window, but every point, valid or not, gets the
+// statistic of its window. A window with fewer than MinPeriods valid
+// observations, or whose statistic is undefined (e.g. RollStd of a single
+// value), yields Meas=NaN and StMissing; other points are StOK.
+//
+// Sums and moments are updated incrementally in O(1), minimum and maximum
+// with monotonic deques in amortized O(1), median and quantiles with a
+// sorted copy of the window, which costs O(window) per step.
+//
+// Errors:
+//   - ErrZeroPeriod if window <= 0.
+//   - ErrBounds on an unknown Stat, a Q outside [0, 1] for RollQuantile,
+//     a nil Reducer for RollCustom or a negative MinPeriods.
+//   - ErrUnsorted if Chron is not strictly increasing.
+func (ts *TimeSeries) Rolling(window time.Duration, opts RollingOpts) (TimeSeries, error) {
+	if window <= 0 {
+		return TimeSeries{Name: ts.Name}, ErrZeroPeriod
+	}
+	ds := ts.DataSeries
+	after := func(t time.Time) int {
+		return sort.Search(len(ds), func(j int) bool { return ds[j].Chron.After(t) })
+	}
+	bounds := func(i int) (int, int) {
+		t := ds[i].Chron
+		if opts.Center {
+			return after(t.Add(-window / 2)), after(t.Add(window / 2))
+		}
+		return after(t.Add(-window)), i + 1
+	}
+	return ts.roll(opts, bounds)
+}
+
+// RollingN is the count-based counterpart of Rolling: each window holds n
+// consecutive points (valid or not), the current one and the n-1 previous
+// ones, or n points around the current one when centered (the extra point
+// of an even n goes before). Windows are truncated at both ends of the
+// series. It shares the semantics of Rolling and returns ErrBounds if
+// n <= 0.
+func (ts *TimeSeries) RollingN(n int, opts RollingOpts) (TimeSeries, error) {
+	if n <= 0 {
+		return TimeSeries{Name: ts.Name}, ErrBounds
+	}
+	size := len(ts.DataSeries)
+	bounds := func(i int) (int, int) {
+		lo := i - n + 1
+		if opts.Center {
+			lo = i - n/2
+		}
+		hi := lo + n
+		if lo < 0 {
+			lo = 0
+		}
+		if hi > size {
+			hi = size
+		}
+		return lo, hi
+	}
+	return ts.roll(opts, bounds)
+}
+
+// roll slides the window [lo, hi) returned by bounds over the series. Both
+// bounds must be non-decreasing in i.
+func (ts *TimeSeries) roll(opts RollingOpts, bounds func(i int) (int, int)) (TimeSeries, error) {
+	out := TimeSeries{Name: ts.Name}
+	switch {
+	case opts.Stat < RollMean || opts.Stat > RollCustom || opts.MinPeriods < 0:
+		return out, ErrBounds
+	case opts.Stat == RollQuantile && (opts.Q < 0 || opts.Q > 1):
+		return out, ErrBounds
+	case opts.Stat == RollCustom && opts.Reducer == nil:
+		return out, ErrBounds
+	}
+	if !ts.strictlyIncreasing() {
+		return out, ErrUnsorted
+	}
+	minPeriods := opts.MinPeriods
+	if minPeriods == 0 && opts.Stat != RollCount {
+		minPeriods = 1
+	}
+
+	acc := newRollAcc(opts)
+	ds := ts.DataSeries
+	lo, hi, n := 0, 0, 0
+	for i, d := range ds {
+		a, b := bounds(i)
+		for ; hi < b; hi++ {
+			if usable(ds[hi], opts.KeepOutliers) {
+				acc.add(hi, ds[hi].Meas)
+				n++
+			}
+		}
+		for ; lo < a; lo++ {
+			if usable(ds[lo], opts.KeepOutliers) {
+				acc.remove(lo, ds[lo].Meas)
+				n--
+			}
+		}
+		du := DataUnit{Chron: d.Chron, Meas: math.NaN(), Status: StMissing}
+		if n >= minPeriods {
+			if v := acc.value(); !math.IsNaN(v) {
+				du.Meas, du.Status = v, StOK
+			}
+		}
+		out.AddDataUnit(du)
+	}
+	return out, nil
+}
+
+// rollAcc maintains a statistic over a window in which values enter and
+// leave in chronological order; i is the position of the value.
+type rollAcc interface {
+	add(i int, v float64)
+	remove(i int, v float64)
+	value() float64
+}
+
+func newRollAcc(opts RollingOpts) rollAcc {
+	switch opts.Stat {
+	case RollMin:
+		return &dequeAcc{less: func(a, b float64) bool { return a < b }}
+	case RollMax:
+		return &dequeAcc{less: func(a, b float64) bool { return a > b }}
+	case RollMedian:
+		return &sortedAcc{q: 0.5}
+	case RollQuantile:
+		return &sortedAcc{q: opts.Q}
+	case RollCustom:
+		return &customAcc{reducer: opts.Reducer}
+	}
+	return &momentAcc{stat: opts.Stat}
+}
+
+// momentAcc keeps the count, sum, mean and sum of squared deviations of the
+// window (Welford's algorithm, with removal).
+type momentAcc struct {
+	stat          RollStat
+	n             int
+	sum, mean, m2 float64
+}
+
+func (m *momentAcc) add(_ int, v float64) {
+	m.n++
+	m.sum += v
+	d := v - m.mean
+	m.mean += d / float64(m.n)
+	m.m2 += d * (v - m.mean)
+}
+
+func (m *momentAcc) remove(_ int, v float64) {
+	m.n--
+	if m.n == 0 {
+		*m = momentAcc{stat: m.stat}
+		return
+	}
+	m.sum -= v
+	d := v - m.mean
+	m.mean -= d / float64(m.n)
+	m.m2 -= d * (v - m.mean)
+}
+
+func (m *momentAcc) value() float64 {
+	switch m.stat {
+	case RollCount:
+		return float64(m.n)
+	case RollSum:
+		return m.sum
+	case RollStd:
+		if m.n < 2 {
+			return math.NaN()
+		}
+		return math.Sqrt(math.Max(m.m2, 0) / float64(m.n-1))
+	}
+	if m.n == 0 {
+		return math.NaN()
+	}
+	return m.mean
+}
+
+// dequeAcc is a monotonic deque: the front holds the extremum and every
+// entry is "better" (per less) than those behind it.
+type dequeAcc struct {
+	less func(a, b float64) bool
+	idx  []int
+	vals []float64
+}
+
+func (q *dequeAcc) add(i int, v float64) {
+	k := len(q.vals)
+	for k > 0 && !q.less(q.vals[k-1], v) {
+		k--
+	}
+	q.idx = append(q.idx[:k], i)
+	q.vals = append(q.vals[:k], v)
+}
+
+func (q *dequeAcc) remove(i int, _ float64) {
+	if len(q.idx) > 0 && q.idx[0] == i {
+		q.idx, q.vals = q.idx[1:], q.vals[1:]
+	}
+}
+
+func (q *dequeAcc) value() float64 {
+	if len(q.vals) == 0 {
+		return math.NaN()
+	}
+	return q.vals[0]
+}
+
+// sortedAcc keeps the window values sorted to read quantile q.
+type sortedAcc struct {
+	q      float64
+	sorted []float64
+}
+
+func (s *sortedAcc) add(_ int, v float64) {
+	k := sort.SearchFloat64s(s.sorted, v)
+	s.sorted = append(s.sorted, 0)
+	copy(s.sorted[k+1:], s.sorted[k:])
+	s.sorted[k] = v
+}
+
+func (s *sortedAcc) remove(_ int, v float64) {
+	k := sort.SearchFloat64s(s.sorted, v)
+	s.sorted = append(s.sorted[:k], s.sorted[k+1:]...)
+}
+
+func (s *sortedAcc) value() float64 {
+	return quantileSorted(s.sorted, s.q)
+}
+
+// quantileSorted returns quantile q of sorted data, linearly interpolated
+// between order statistics (the default of R and NumPy), or NaN if data is
+// empty.
+func quantileSorted(sorted []float64, q float64) float64 {
+	n := len(sorted)
+	if n == 0 {
+		return math.NaN()
+	}
+	h := q * float64(n-1)
+	k := int(math.Floor(h))
+	if k >= n-1 {
+		return sorted[n-1]
+	}
+	return sorted[k] + (h-float64(k))*(sorted[k+1]-sorted[k])
+}
+
+// customAcc queues the window values for a caller-supplied reducer.
+type customAcc struct {
+	reducer func(vals []float64) float64
+	vals    []float64
+	scratch []float64
+}
+
+func (c *customAcc) add(_ int, v float64) {
+	c.vals = append(c.vals, v)
+}
+
+func (c *customAcc) remove(_ int, _ float64) {
+	c.vals = c.vals[1:]
+}
+
+func (c *customAcc) value() float64 {
+	c.scratch = append(c.scratch[:0], c.vals...)
+	return c.reducer(c.scratch)
+}
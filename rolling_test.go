@@ -0,0 +1,140 @@
+package timeseries
+
+import (
+	"math"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestRolling_TimeWindowIrregular(t *testing.T) {
+	// Points at 0, 1, 2, 5, 6 minutes.
+	t0 := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	var ts TimeSeries
+	for i, m := range []int{0, 1, 2, 5, 6} {
+		ts.AddData(t0.Add(time.Duration(m)*time.Minute), float64(i+1))
+	}
+	cases := []struct {
+		stat RollStat
+		want []float64
+	}{
+		{RollMean, []float64{1, 1.5, 2, 4, 4.5}},
+		{RollSum, []float64{1, 3, 6, 4, 9}},
+		{RollMin, []float64{1, 1, 1, 4, 4}},
+		{RollMax, []float64{1, 2, 3, 4, 5}},
+		{RollCount, []float64{1, 2, 3, 1, 2}},
+		{RollMedian, []float64{1, 1.5, 2, 4, 4.5}},
+		{RollStd, []float64{math.NaN(), math.Sqrt(0.5), 1, math.NaN(), math.Sqrt(0.5)}},
+	}
+	for _, c := range cases {
+		got, err := ts.Rolling(3*time.Minute, RollingOpts{Stat: c.stat})
+		if err != nil {
+			t.Fatalf("stat %v: unexpected error: %v", c.stat, err)
+		}
+		for i, w := range c.want {
+			if !almostEq(got.DataSeries[i].Meas, w, 1e-12) {
+				t.Fatalf("stat %v point %d: got %v, want %v", c.stat, i, got.DataSeries[i].Meas, w)
+			}
+		}
+	}
+}
+
+func TestRolling_CenterMinPeriodsAndInvalid(t *testing.T) {
+	ts := mkTS(1, 2, 100, 4, 5)
+	ts.DataSeries[2].Status = StInvalid
+	got, err := ts.Rolling(2*time.Minute, RollingOpts{Stat: RollMean, Center: true, MinPeriods: 2})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// Centered windows are (t-1m, t+1m]: the point itself and the next one.
+	want := []float64{1.5, math.NaN(), math.NaN(), 4.5, math.NaN()}
+	for i, w := range want {
+		if !almostEq(got.DataSeries[i].Meas, w, 1e-12) {
+			t.Fatalf("point %d: got %v, want %v", i, got.DataSeries[i].Meas, w)
+		}
+	}
+	if got.DataSeries[1].Status != StMissing || got.DataSeries[0].Status != StOK {
+		t.Fatalf("statuses: %v, %v", got.DataSeries[0].Status, got.DataSeries[1].Status)
+	}
+}
+
+func TestRollingN_QuantileAndCustom(t *testing.T) {
+	ts := mkTS(5, 1, 4, 2, 3)
+	q, err := ts.RollingN(3, RollingOpts{Stat: RollQuantile, Q: 0.25})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []float64{5, 2, 2.5, 1.5, 2.5}
+	for i, w := range want {
+		if !almostEq(q.DataSeries[i].Meas, w, 1e-12) {
+			t.Fatalf("quantile point %d: got %v, want %v", i, q.DataSeries[i].Meas, w)
+		}
+	}
+	rng := func(v []float64) float64 {
+		sort.Float64s(v)
+		return v[len(v)-1] - v[0]
+	}
+	c, err := ts.RollingN(3, RollingOpts{Stat: RollCustom, Reducer: rng, Center: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want = []float64{4, 4, 3, 2, 1}
+	for i, w := range want {
+		if c.DataSeries[i].Meas != w {
+			t.Fatalf("custom point %d: got %v, want %v", i, c.DataSeries[i].Meas, w)
+		}
+	}
+}
+
+func TestRolling_MatchesBruteForce(t *testing.T) {
+	vals := []float64{3, 9, 1, 7, 7, 2, 8, 6, 0, 4, 5, 5, 11, -2, 3}
+	ts := mkTS(vals...)
+	for _, stat := range []RollStat{RollMin, RollMax, RollMedian, RollMean} {
+		got, err := ts.RollingN(4, RollingOpts{Stat: stat})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		for i := range vals {
+			lo := i - 3
+			if lo < 0 {
+				lo = 0
+			}
+			w := append([]float64(nil), vals[lo:i+1]...)
+			sort.Float64s(w)
+			var want float64
+			switch stat {
+			case RollMin:
+				want = w[0]
+			case RollMax:
+				want = w[len(w)-1]
+			case RollMedian:
+				want = quantileSorted(w, 0.5)
+			case RollMean:
+				want, _ = Mean(w)
+			}
+			if !almostEq(got.DataSeries[i].Meas, want, 1e-9) {
+				t.Fatalf("stat %v point %d: got %v, want %v", stat, i, got.DataSeries[i].Meas, want)
+			}
+		}
+	}
+}
+
+func TestRolling_Errors(t *testing.T) {
+	ts := mkTS(1, 2, 3)
+	if _, err := ts.Rolling(0, RollingOpts{}); err != ErrZeroPeriod {
+		t.Fatalf("got %v, want ErrZeroPeriod", err)
+	}
+	if _, err := ts.Rolling(time.Minute, RollingOpts{Stat: RollQuantile, Q: 2}); err != ErrBounds {
+		t.Fatalf("got %v, want ErrBounds", err)
+	}
+	if _, err := ts.RollingN(2, RollingOpts{Stat: RollCustom}); err != ErrBounds {
+		t.Fatalf("got %v, want ErrBounds", err)
+	}
+	if _, err := ts.RollingN(0, RollingOpts{}); err != ErrBounds {
+		t.Fatalf("got %v, want ErrBounds", err)
+	}
+	ts.DataSeries[0], ts.DataSeries[1] = ts.DataSeries[1], ts.DataSeries[0]
+	if _, err := ts.Rolling(time.Minute, RollingOpts{}); err != ErrUnsorted {
+		t.Fatalf("got %v, want ErrUnsorted", err)
+	}
+}
@@ -0,0 +1,195 @@
+package timeseries
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// SHESDOpts configures SHESD, the seasonal hybrid ESD anomaly detector.
+//
+// Fields:
+//   - Period:      the seasonal period, e.g. 24h for a daily profile or
+//     7*24h for a weekly one; must be > 0.
+//   - Anchor:      the origin of the phases. The zero value means the Unix
+//     epoch, i.e. midnight UTC; use local midnight for daily profiles of
+//     local activity.
+//   - Bin:         phase resolution: points whose offset in the period falls
+//     in the same Bin share a seasonal value. 0 means exact offsets, which
+//     suits regular series.
+//   - TrendWindow: width of the centered rolling median giving the trend.
+//     0 means Period.
+//   - MaxAnoms:    upper bound on the fraction of anomalies, in (0, 0.5).
+//     0 means 0.1.
+//   - Alpha:       significance level of the ESD test. 0 means 0.05.
+//   - Tail:        which anomalies are sought: above (TailUpper), below
+//     (TailLower) or both.
+type SHESDOpts struct {
+	Period      time.Duration
+	Anchor      time.Time
+	Bin         time.Duration
+	TrendWindow time.Duration
+	MaxAnoms    float64
+	Alpha       float64
+	Tail        Tail
+}
+
+// Anomaly is a point found anomalous by SHESD.
+//
+// Fields:
+//   - Index:     position of the point in DataSeries.
+//   - Chron:     its timestamp.
+//   - Meas:      its value.
+//   - Expected:  trend plus seasonal value at that time.
+//   - Score:     the robust studentized residual (ESD statistic).
+//   - Direction: TailUpper if the point is above what was expected,
+//     TailLower if below.
+type Anomaly struct {
+	Index     int
+	Chron     time.Time
+	Meas      float64
+	Expected  float64
+	Score     float64
+	Direction Tail
+}
+
+// SHESD detects anomalies in a periodic series with the seasonal hybrid
+// ESD method (Hochenbaum et al., 2017): a value is judged against what is
+// usual at the same phase of the period, e.g. the same time of day, not
+// against the whole series, so recurring peaks are not anomalies.
+//
+// Steps, on the valid points only:
+//  1. trend: centered rolling median over TrendWindow;
+//  2. seasonality: median of the detrended values of each phase, the phase
+//     of a point being its offset from Anchor modulo Period, truncated to
+//     Bin;
+//  3. residual = value - trend - seasonal;
+//  4. robust generalized ESD (median and MAD instead of mean and standard
+//     deviation) on the residuals, for at most MaxAnoms*n anomalies.
+//
+// Anomalies are returned in chronological order.
+//
+// Errors:
+//   - ErrZeroPeriod if Period <= 0.
+//   - ErrBounds if an option is out of range.
+//   - ErrUnsorted if Chron is not strictly increasing.
+//   - ErrSize if the valid points span less than two periods.
+func (ts *TimeSeries) SHESD(opts SHESDOpts) ([]Anomaly, error) {
+	if opts.Period <= 0 {
+		return nil, ErrZeroPeriod
+	}
+	if opts.TrendWindow == 0 {
+		opts.TrendWindow = opts.Period
+	}
+	if opts.MaxAnoms == 0 {
+		opts.MaxAnoms = 0.1
+	}
+	if opts.Alpha == 0 {
+		opts.Alpha = 0.05
+	}
+	if opts.Bin < 0 || opts.TrendWindow < 0 || opts.MaxAnoms <= 0 || opts.MaxAnoms >= 0.5 ||
+		opts.Alpha <= 0 || opts.Alpha >= 1 || opts.Tail < TailBoth || opts.Tail > TailLower {
+		return nil, ErrBounds
+	}
+	if !ts.strictlyIncreasing() {
+		return nil, ErrUnsorted
+	}
+	if opts.Anchor.IsZero() {
+		opts.Anchor = time.Unix(0, 0).UTC()
+	}
+
+	var valid TimeSeries
+	var pos []int
+	for i, d := range ts.DataSeries {
+		if usable(d, false) {
+			valid.AddDataUnit(d)
+			pos = append(pos, i)
+		}
+	}
+	n := len(valid.DataSeries)
+	if n < 3 || valid.DataSeries[n-1].Chron.Sub(valid.DataSeries[0].Chron) < 2*opts.Period {
+		return nil, ErrSize
+	}
+
+	trend, err := valid.Rolling(opts.TrendWindow, RollingOpts{Stat: RollMedian, Center: true})
+	if err != nil {
+		return nil, err
+	}
+	phase := func(t time.Time) time.Duration {
+		off := t.Sub(opts.Anchor) % opts.Period
+		if off < 0 {
+			off += opts.Period
+		}
+		if opts.Bin > 0 {
+			off -= off % opts.Bin
+		}
+		return off
+	}
+	byPhase := make(map[time.Duration][]float64)
+	for i, d := range valid.DataSeries {
+		p := phase(d.Chron)
+		byPhase[p] = append(byPhase[p], d.Meas-trend.DataSeries[i].Meas)
+	}
+	seasonal := make(map[time.Duration]float64, len(byPhase))
+	for p, vals := range byPhase {
+		seasonal[p], _ = Median(vals)
+	}
+
+	expected := make([]float64, n)
+	resid := make([]float64, n)
+	for i, d := range valid.DataSeries {
+		expected[i] = trend.DataSeries[i].Meas + seasonal[phase(d.Chron)]
+		resid[i] = d.Meas - expected[i]
+	}
+
+	maxAnoms := int(math.Floor(opts.MaxAnoms * float64(n)))
+	if maxAnoms < 1 {
+		return nil, nil
+	}
+	res := gesd(resid, maxAnoms, opts.Alpha, opts.Tail, true)
+	sort.Ints(res.Rejected)
+
+	anoms := make([]Anomaly, 0, len(res.Rejected))
+	for _, k := range res.Rejected {
+		a := Anomaly{
+			Index:     pos[k],
+			Chron:     valid.DataSeries[k].Chron,
+			Meas:      valid.DataSeries[k].Meas,
+			Expected:  expected[k],
+			Direction: TailUpper,
+		}
+		for step, c := range res.Candidates {
+			if c == k {
+				a.Score = res.Statistics[step]
+			}
+		}
+		if resid[k] < 0 {
+			a.Direction = TailLower
+		}
+		anoms = append(anoms, a)
+	}
+	return anoms, nil
+}
+
+// SHESDDetector flags the anomalies found by SHESD.
+type SHESDDetector struct {
+	Opts SHESDOpts
+}
+
+// Detect implements Detector. The threshold is the significance level of
+// the ESD test, since its critical value changes at every step.
+func (s SHESDDetector) Detect(ts *TimeSeries) []Flag {
+	anoms, err := ts.SHESD(s.Opts)
+	if err != nil {
+		return nil
+	}
+	alpha := s.Opts.Alpha
+	if alpha == 0 {
+		alpha = 0.05
+	}
+	flags := make([]Flag, 0, len(anoms))
+	for _, a := range anoms {
+		flags = append(flags, Flag{Index: a.Index, Chron: a.Chron, Meas: a.Meas, Detector: "shesd", Threshold: alpha})
+	}
+	return flags
+}
@@ -0,0 +1,85 @@
+package timeseries
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+// dailyProfile returns hourly data over days with an afternoon peak.
+func dailyProfile(days int) TimeSeries {
+	t0 := time.Date(2025, 3, 3, 0, 0, 0, 0, time.UTC)
+	ts := TimeSeries{Name: "building"}
+	rng := rand.New(rand.NewSource(1))
+	for h := 0; h < days*24; h++ {
+		hour := h % 24
+		v := 20 + 0.01*float64(h) // slow drift
+		if hour >= 13 && hour <= 16 {
+			v += 30 // afternoon peak
+		}
+		v += 0.3 * rng.NormFloat64()
+		ts.AddData(t0.Add(time.Duration(h)*time.Hour), v)
+	}
+	return ts
+}
+
+func TestSHESD_PeaksAreNotAnomalies(t *testing.T) {
+	ts := dailyProfile(14)
+	// A night-time spike and a missing afternoon peak.
+	ts.DataSeries[5*24+3].Meas += 25
+	ts.DataSeries[9*24+14].Meas -= 30
+
+	anoms, err := ts.SHESD(SHESDOpts{Period: 24 * time.Hour, MaxAnoms: 0.05})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(anoms) != 2 {
+		t.Fatalf("got %d anomalies, want 2: %+v", len(anoms), anoms)
+	}
+	if anoms[0].Index != 5*24+3 || anoms[0].Direction != TailUpper {
+		t.Fatalf("first anomaly %+v, want the night spike upward", anoms[0])
+	}
+	if anoms[1].Index != 9*24+14 || anoms[1].Direction != TailLower {
+		t.Fatalf("second anomaly %+v, want the missing peak downward", anoms[1])
+	}
+	if math.Abs(anoms[1].Expected-(anoms[1].Meas+30)) > 2 {
+		t.Fatalf("expected value %v, want about %v", anoms[1].Expected, anoms[1].Meas+30)
+	}
+
+	// Against the whole series, a z-score flags peaks instead.
+	z := ZscoreDetector{Level: 1.5}.Detect(&ts)
+	if len(z) < 10 {
+		t.Fatalf("z-score flagged %d points, expected the afternoon peaks", len(z))
+	}
+}
+
+func TestSHESD_TailAndDetector(t *testing.T) {
+	ts := dailyProfile(14)
+	ts.DataSeries[50].Meas += 20
+	ts.DataSeries[100].Meas -= 20
+	anoms, err := ts.SHESD(SHESDOpts{Period: 24 * time.Hour, Tail: TailLower})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(anoms) != 1 || anoms[0].Index != 100 {
+		t.Fatalf("lower tail: got %+v", anoms)
+	}
+	flags := ts.Flag(SHESDDetector{Opts: SHESDOpts{Period: 24 * time.Hour}})
+	if len(flags) != 2 || ts.DataSeries[50].Status != StOutlier || flags[0].Detector != "shesd" {
+		t.Fatalf("detector: got %+v", flags)
+	}
+}
+
+func TestSHESD_Errors(t *testing.T) {
+	ts := dailyProfile(1)
+	if _, err := ts.SHESD(SHESDOpts{}); err != ErrZeroPeriod {
+		t.Fatalf("got %v, want ErrZeroPeriod", err)
+	}
+	if _, err := ts.SHESD(SHESDOpts{Period: 24 * time.Hour}); err != ErrSize {
+		t.Fatalf("got %v, want ErrSize", err)
+	}
+	if _, err := ts.SHESD(SHESDOpts{Period: time.Hour, MaxAnoms: 0.6}); err != ErrBounds {
+		t.Fatalf("got %v, want ErrBounds", err)
+	}
+}
@@ -6,8 +6,9 @@ import (
 
 func SortChronoAsc(s TimeSeries, name string) TimeSeries {
 	sorted := TimeSeries{
-		Name:       s.Name,
-		DataSeries: append([]DataUnit(nil), s.DataSeries...), // copie indépendante
+		Name:        s.Name,
+		DataSeries:  append([]DataUnit(nil), s.DataSeries...), // copie indépendante
+		Annotations: s.copyAnnotations(),
 	}
 	sort.Slice(sorted.DataSeries, func(i, j int) bool {
 		return sorted.DataSeries[i].Chron.Before(sorted.DataSeries[j].Chron)
@@ -17,8 +18,9 @@ func SortChronoAsc(s TimeSeries, name string) TimeSeries {
 }
 func SortChronoDesc(s TimeSeries, name string) TimeSeries {
 	sorted := TimeSeries{
-		Name:       s.Name,
-		DataSeries: append([]DataUnit(nil), s.DataSeries...), // copie indépendante
+		Name:        s.Name,
+		DataSeries:  append([]DataUnit(nil), s.DataSeries...), // copie indépendante
+		Annotations: s.copyAnnotations(),
 	}
 	sort.Slice(sorted.DataSeries, func(i, j int) bool {
 		return sorted.DataSeries[i].Chron.After(sorted.DataSeries[j].Chron)
@@ -28,8 +30,9 @@ func SortChronoDesc(s TimeSeries, name string) TimeSeries {
 }
 func SortMeasAsc(s TimeSeries, name string) TimeSeries {
 	sorted := TimeSeries{
-		Name:       s.Name,
-		DataSeries: append([]DataUnit(nil), s.DataSeries...), // copie indépendante
+		Name:        s.Name,
+		DataSeries:  append([]DataUnit(nil), s.DataSeries...), // copie indépendante
+		Annotations: s.copyAnnotations(),
 	}
 	sort.Slice(sorted.DataSeries, func(i, j int) bool {
 		return sorted.DataSeries[i].Chron.Before(sorted.DataSeries[j].Chron)
@@ -39,8 +42,9 @@ func SortMeasAsc(s TimeSeries, name string) TimeSeries {
 }
 func SortMeasDesc(s TimeSeries, name string) TimeSeries {
 	sorted := TimeSeries{
-		Name:       s.Name,
-		DataSeries: append([]DataUnit(nil), s.DataSeries...), // copie indépendante
+		Name:        s.Name,
+		DataSeries:  append([]DataUnit(nil), s.DataSeries...), // copie indépendante
+		Annotations: s.copyAnnotations(),
 	}
 	sort.Slice(sorted.DataSeries, func(i, j int) bool {
 		return sorted.DataSeries[i].Chron.After(sorted.DataSeries[j].Chron)
@@ -52,8 +56,9 @@ func SortMeasDesc(s TimeSeries, name string) TimeSeries {
 // SortedMeasAsc retourne une nouvelle Series triée par Meas croissant
 func SortedMeasAsc(s TimeSeries) TimeSeries {
 	sorted := TimeSeries{
-		Name:       s.Name,
-		DataSeries: append([]DataUnit(nil), s.DataSeries...), // copie indépendante
+		Name:        s.Name,
+		DataSeries:  append([]DataUnit(nil), s.DataSeries...), // copie indépendante
+		Annotations: s.copyAnnotations(),
 	}
 	sort.Slice(sorted.DataSeries, func(i, j int) bool {
 		return sorted.DataSeries[i].Meas < sorted.DataSeries[j].Meas
@@ -64,8 +69,9 @@ func SortedMeasAsc(s TimeSeries) TimeSeries {
 // SortedMeasDesc retourne une nouvelle Series triée par Meas décroissant
 func SortedMeasDesc(s TimeSeries) TimeSeries {
 	sorted := TimeSeries{
-		Name:       s.Name,
-		DataSeries: append([]DataUnit(nil), s.DataSeries...),
+		Name:        s.Name,
+		DataSeries:  append([]DataUnit(nil), s.DataSeries...),
+		Annotations: s.copyAnnotations(),
 	}
 	sort.Slice(sorted.DataSeries, func(i, j int) bool {
 		return sorted.DataSeries[i].Meas > sorted.DataSeries[j].Meas
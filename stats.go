@@ -64,6 +64,24 @@ func Median(input []float64) (median float64, err error) {
 	return median, nil
 }
 
+// MAD returns the median absolute deviation of input, median(|x - median|),
+// together with the median itself. It is the robust counterpart of StdDev:
+// for normal data, 1.4826*MAD estimates the standard deviation. If input is
+// empty, it returns math.NaN() twice and ErrEmptyInput. Input is not
+// modified.
+func MAD(input []float64) (mad float64, median float64, err error) {
+	if len(input) == 0 {
+		return math.NaN(), math.NaN(), ErrEmptyInput
+	}
+	cp := append([]float64(nil), input...)
+	median, _ = Median(cp)
+	for i, v := range cp {
+		cp[i] = math.Abs(v - median)
+	}
+	mad, _ = Median(cp)
+	return mad, median, nil
+}
+
 // Min finds the lowest number in a set of data
 func Min(input []float64) (min float64, err error) {
 
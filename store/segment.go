@@ -0,0 +1,112 @@
+package store
+
+import (
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/usefulrisk/timeseries"
+)
+
+// segExt is the extension of segment files; tmpExt marks segments being
+// written, which Open discards.
+const (
+	segExt = ".seg"
+	tmpExt = ".tmp"
+)
+
+// segment is an immutable file holding the points of one series within one
+// time partition, Gorilla-encoded (see timeseries.EncodeGorilla). Its file
+// name is "<hex name>_<start ns>_<end ns>_<seq>.seg", [start, end) being
+// the partition. Segments of the same series and partition may overlap: on
+// equal timestamps, the highest seq wins.
+type segment struct {
+	name       string
+	start, end int64 // partition bounds, in Unix ns
+	seq        uint64
+	path       string
+}
+
+// fileName returns the base name of the segment file.
+func (sg segment) fileName() string {
+	return fmt.Sprintf("%s_%d_%d_%d%s", hex.EncodeToString([]byte(sg.name)), sg.start, sg.end, sg.seq, segExt)
+}
+
+// parseSegment parses a segment file name; ok is false for other files.
+func parseSegment(dir, base string) (segment, bool) {
+	parts := strings.Split(strings.TrimSuffix(base, segExt), "_")
+	if !strings.HasSuffix(base, segExt) || len(parts) != 4 {
+		return segment{}, false
+	}
+	name, err1 := hex.DecodeString(parts[0])
+	start, err2 := strconv.ParseInt(parts[1], 10, 64)
+	end, err3 := strconv.ParseInt(parts[2], 10, 64)
+	seq, err4 := strconv.ParseUint(parts[3], 10, 64)
+	if err1 != nil || err2 != nil || err3 != nil || err4 != nil {
+		return segment{}, false
+	}
+	return segment{name: string(name), start: start, end: end, seq: seq, path: filepath.Join(dir, base)}, true
+}
+
+// overlaps reports whether the partition of sg meets [from, to).
+func (sg segment) overlaps(from, to time.Time) bool {
+	return sg.start < to.UnixNano() && sg.end > from.UnixNano()
+}
+
+// writeSegment writes the points of ds, which all lie in sg's partition,
+// to a temporary file, syncs it, then renames it into place so that a
+// segment is either absent or complete.
+func writeSegment(sg segment, ds []timeseries.DataUnit, blockSize int) error {
+	ts := timeseries.TimeSeries{Name: sg.name, DataSeries: ds}
+	b, err := ts.EncodeGorilla(blockSize)
+	if err != nil {
+		return err
+	}
+	tmp := sg.path + tmpExt
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(b); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, sg.path)
+}
+
+// readSegment returns the points of the segment lying in [from, to).
+func readSegment(sg segment, from, to time.Time) ([]timeseries.DataUnit, error) {
+	b, err := os.ReadFile(sg.path)
+	if err != nil {
+		return nil, err
+	}
+	ts, err := timeseries.DecodeGorillaRange(b, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("segment %s: %w", filepath.Base(sg.path), err)
+	}
+	return ts.DataSeries, nil
+}
+
+// syncDir makes renames and removals in dir durable.
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
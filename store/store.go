@@ -0,0 +1,390 @@
+// Package store persists the series of a timeseries.TsContainer in a local
+// directory, with no dependency beyond the standard library, for edge
+// gateways and other single-process deployments.
+//
+// Points are first appended to a write-ahead log (WAL) and kept in memory.
+// Flush moves them into immutable segments, one per series and time
+// partition, Gorilla-compressed (see timeseries.EncodeGorilla), then
+// empties the log. On Open, segments are listed and the log is replayed, so
+// that points appended before a crash are not lost. Compact merges the
+// segments of a same series and partition.
+//
+// Writing a point again at the same timestamp replaces it: queries keep
+// the most recent write. Flush, Compact and recovery rely on this to stay
+// safe when interrupted.
+//
+// A Store is safe for concurrent use by multiple goroutines, but a
+// directory must be opened by one Store at a time.
+package store
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/usefulrisk/timeseries"
+)
+
+// ErrClosed is returned by the methods of a closed Store.
+var ErrClosed = errors.New("store: closed")
+
+// Options tunes a Store. The zero value gives the defaults.
+//
+// Fields:
+//   - Partition:   time span of a segment. 0 means 24h.
+//   - BlockSize:   Gorilla block size of segments. 0 means
+//     timeseries.DefaultGorillaBlock.
+//   - FlushPoints: number of points in memory that triggers a Flush from
+//     Append. 0 means 65536; negative disables automatic flushes.
+//   - Sync:        fsync the WAL after every Append, so that appended points
+//     survive a power loss and not only a process crash.
+type Options struct {
+	Partition   time.Duration
+	BlockSize   int
+	FlushPoints int
+	Sync        bool
+}
+
+// Store is an on-disk store of named series. Use Open to create one.
+type Store struct {
+	mu   sync.Mutex
+	dir  string
+	opts Options
+	wal  *os.File
+	buf  []byte
+	mem  map[string][]timeseries.DataUnit
+	memN int
+	segs []segment
+	seq  uint64 // highest segment sequence number in use
+}
+
+// Open opens the store in dir, creating the directory if needed. It
+// discards the temporary files of interrupted flushes or compactions and
+// replays the WAL, dropping a record torn by a crash.
+//
+// Errors: timeseries.ErrBounds for negative options, and file system
+// errors.
+func Open(dir string, opts Options) (*Store, error) {
+	if opts.Partition < 0 || opts.BlockSize < 0 {
+		return nil, timeseries.ErrBounds
+	}
+	if opts.Partition == 0 {
+		opts.Partition = 24 * time.Hour
+	}
+	if opts.FlushPoints == 0 {
+		opts.FlushPoints = 1 << 16
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	s := &Store{dir: dir, opts: opts, mem: make(map[string][]timeseries.DataUnit)}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range entries {
+		if strings.HasSuffix(e.Name(), tmpExt) {
+			if err := os.Remove(filepath.Join(dir, e.Name())); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if sg, ok := parseSegment(dir, e.Name()); ok {
+			s.segs = append(s.segs, sg)
+			s.seq = max(s.seq, sg.seq)
+		}
+	}
+
+	walPath := filepath.Join(dir, walName)
+	size, err := replayWAL(walPath, func(name string, d timeseries.DataUnit) {
+		s.mem[name] = append(s.mem[name], d)
+		s.memN++
+	})
+	if err != nil {
+		return nil, err
+	}
+	if s.wal, err = openWAL(walPath, size); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Append writes points of the named series to the WAL and keeps them in
+// memory until the next Flush. Points need not be in time order.
+func (s *Store) Append(name string, ds ...timeseries.DataUnit) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.wal == nil {
+		return ErrClosed
+	}
+	s.buf = s.buf[:0]
+	for _, d := range ds {
+		s.buf = appendWALRecord(s.buf, name, d)
+	}
+	if _, err := s.wal.Write(s.buf); err != nil {
+		return err
+	}
+	if s.opts.Sync {
+		if err := s.wal.Sync(); err != nil {
+			return err
+		}
+	}
+	s.mem[name] = append(s.mem[name], ds...)
+	s.memN += len(ds)
+	if s.opts.FlushPoints > 0 && s.memN >= s.opts.FlushPoints {
+		return s.flush()
+	}
+	return nil
+}
+
+// Write appends every series of the container under its key.
+func (s *Store) Write(tsc *timeseries.TsContainer) error {
+	for name, ts := range tsc.Ts {
+		if ts == nil {
+			continue
+		}
+		if err := s.Append(name, ts.DataSeries...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Flush writes the points held in memory to new segments, then empties
+// the WAL.
+func (s *Store) Flush() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.wal == nil {
+		return ErrClosed
+	}
+	return s.flush()
+}
+
+func (s *Store) flush() error {
+	if s.memN == 0 {
+		return nil
+	}
+	names := make([]string, 0, len(s.mem))
+	for name := range s.mem {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		parts := make(map[int64][]timeseries.DataUnit)
+		for _, d := range s.mem[name] {
+			start := d.Chron.Truncate(s.opts.Partition).UnixNano()
+			parts[start] = append(parts[start], d)
+		}
+		starts := make([]int64, 0, len(parts))
+		for start := range parts {
+			starts = append(starts, start)
+		}
+		sort.Slice(starts, func(i, j int) bool { return starts[i] < starts[j] })
+		for _, start := range starts {
+			if err := s.addSegment(name, start, start+int64(s.opts.Partition), parts[start]); err != nil {
+				return err
+			}
+		}
+	}
+	if err := syncDir(s.dir); err != nil {
+		return err
+	}
+
+	// The points are now in segments: a crash before the WAL is emptied
+	// only replays them again, which is harmless.
+	if err := s.wal.Truncate(0); err != nil {
+		return err
+	}
+	if _, err := s.wal.Seek(0, 0); err != nil {
+		return err
+	}
+	if err := s.wal.Sync(); err != nil {
+		return err
+	}
+	s.mem = make(map[string][]timeseries.DataUnit)
+	s.memN = 0
+	return nil
+}
+
+// addSegment writes the points of one series and partition, deduplicated
+// and in time order, to a new segment.
+func (s *Store) addSegment(name string, start, end int64, ds []timeseries.DataUnit) error {
+	sg := segment{name: name, start: start, end: end, seq: s.seq + 1}
+	sg.path = filepath.Join(s.dir, sg.fileName())
+	if err := writeSegment(sg, latest(ds), s.opts.BlockSize); err != nil {
+		return err
+	}
+	s.seq++
+	s.segs = append(s.segs, sg)
+	return nil
+}
+
+// Query returns the points of the named series whose timestamp lies in
+// [from, to), in time order, with deltas filled. Times are in UTC.
+//
+// Errors: timeseries.ErrUnknownSeries if the store holds no point of that
+// series, ErrClosed, and file or decoding errors.
+func (s *Store) Query(name string, from, to time.Time) (timeseries.TimeSeries, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.wal == nil {
+		return timeseries.TimeSeries{}, ErrClosed
+	}
+
+	known := len(s.mem[name]) > 0
+	var all []timeseries.DataUnit
+	for _, sg := range s.sortedSegments() {
+		if sg.name != name {
+			continue
+		}
+		known = true
+		if !sg.overlaps(from, to) {
+			continue
+		}
+		ds, err := readSegment(sg, from, to)
+		if err != nil {
+			return timeseries.TimeSeries{}, err
+		}
+		all = append(all, ds...)
+	}
+	if !known {
+		return timeseries.TimeSeries{}, timeseries.ErrUnknownSeries
+	}
+	for _, d := range s.mem[name] {
+		if !d.Chron.Before(from) && d.Chron.Before(to) {
+			d.Chron = d.Chron.UTC()
+			all = append(all, d)
+		}
+	}
+
+	ts := timeseries.TimeSeries{Name: name, DataSeries: latest(all)}
+	ts.DeltasFiller()
+	return ts, nil
+}
+
+// Names returns the names of the stored series, in lexical order.
+func (s *Store) Names() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	seen := make(map[string]bool)
+	for name := range s.mem {
+		seen[name] = true
+	}
+	for _, sg := range s.segs {
+		seen[sg.name] = true
+	}
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Compact merges the segments of each series and partition into a single
+// one. The merged segment is written before the old ones are removed, so
+// an interrupted compaction leaves at worst redundant segments.
+func (s *Store) Compact() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.wal == nil {
+		return ErrClosed
+	}
+
+	type key struct {
+		name  string
+		start int64
+	}
+	groups := make(map[key][]segment)
+	var keys []key
+	for _, sg := range s.sortedSegments() {
+		k := key{sg.name, sg.start}
+		if groups[k] == nil {
+			keys = append(keys, k)
+		}
+		groups[k] = append(groups[k], sg)
+	}
+
+	for _, k := range keys {
+		group := groups[k]
+		if len(group) == 1 {
+			continue
+		}
+		var all []timeseries.DataUnit
+		for _, sg := range group {
+			ds, err := readSegment(sg, time.Unix(0, sg.start), time.Unix(0, sg.end))
+			if err != nil {
+				return err
+			}
+			all = append(all, ds...)
+		}
+		last := group[len(group)-1]
+		if err := s.addSegment(k.name, k.start, last.end, all); err != nil {
+			return err
+		}
+		for _, sg := range group {
+			if err := os.Remove(sg.path); err != nil {
+				return err
+			}
+			s.dropSegment(sg.seq)
+		}
+	}
+	return syncDir(s.dir)
+}
+
+// Close flushes the points held in memory and closes the WAL. Further
+// calls return ErrClosed.
+func (s *Store) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.wal == nil {
+		return ErrClosed
+	}
+	err := s.flush()
+	if cerr := s.wal.Close(); err == nil {
+		err = cerr
+	}
+	s.wal = nil
+	return err
+}
+
+// sortedSegments returns the segments by increasing sequence number, that
+// is from the oldest write to the newest.
+func (s *Store) sortedSegments() []segment {
+	segs := append([]segment(nil), s.segs...)
+	sort.Slice(segs, func(i, j int) bool { return segs[i].seq < segs[j].seq })
+	return segs
+}
+
+// dropSegment forgets the segment with sequence number seq.
+func (s *Store) dropSegment(seq uint64) {
+	for i, sg := range s.segs {
+		if sg.seq == seq {
+			s.segs = append(s.segs[:i], s.segs[i+1:]...)
+			return
+		}
+	}
+}
+
+// latest returns the points of ds in time order, keeping for each
+// timestamp the last one in ds.
+func latest(ds []timeseries.DataUnit) []timeseries.DataUnit {
+	out := append([]timeseries.DataUnit(nil), ds...)
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Chron.Before(out[j].Chron) })
+	n := 0
+	for i, d := range out {
+		if i+1 < len(out) && out[i+1].Chron.Equal(d.Chron) {
+			continue
+		}
+		out[n] = d
+		n++
+	}
+	return out[:n]
+}
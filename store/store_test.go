@@ -0,0 +1,179 @@
+package store
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/usefulrisk/timeseries"
+)
+
+var t0 = time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// hourly returns n hourly points from t0 with values v0, v0+1, ...
+func hourly(n int, v0 float64) []timeseries.DataUnit {
+	ds := make([]timeseries.DataUnit, n)
+	for i := range ds {
+		ds[i] = timeseries.NewDataUnit(t0.Add(time.Duration(i)*time.Hour), v0+float64(i))
+	}
+	return ds
+}
+
+func segmentCount(t *testing.T, dir string) int {
+	t.Helper()
+	m, err := filepath.Glob(filepath.Join(dir, "*"+segExt))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return len(m)
+}
+
+func TestStore_FlushAndQueryAcrossPartitions(t *testing.T) {
+	dir := t.TempDir()
+	s, err := Open(dir, Options{})
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer s.Close()
+
+	ds := hourly(72, 0)
+	ds[5].Meas, ds[5].Status = math.NaN(), timeseries.StMissing
+	if err := s.Append("temp", ds...); err != nil {
+		t.Fatalf("append: %v", err)
+	}
+	if err := s.Flush(); err != nil {
+		t.Fatalf("flush: %v", err)
+	}
+	if n := segmentCount(t, dir); n != 3 {
+		t.Fatalf("72 hours must give 3 daily segments, got %d", n)
+	}
+	// Unflushed points are visible too.
+	if err := s.Append("temp", timeseries.NewDataUnit(t0.Add(72*time.Hour), 72)); err != nil {
+		t.Fatalf("append: %v", err)
+	}
+
+	got, err := s.Query("temp", t0.Add(20*time.Hour), t0.Add(100*time.Hour))
+	if err != nil {
+		t.Fatalf("query: %v", err)
+	}
+	if len(got.DataSeries) != 53 || got.DataSeries[0].Meas != 20 || got.DataSeries[52].Meas != 72 {
+		t.Fatalf("unexpected result: %d points, first %+v", len(got.DataSeries), got.DataSeries[0])
+	}
+	if got.DataSeries[1].Dchron != time.Hour {
+		t.Fatalf("deltas not filled: %+v", got.DataSeries[1])
+	}
+	if got, _ := s.Query("temp", t0, t0.Add(6*time.Hour)); got.DataSeries[5].Status != timeseries.StMissing {
+		t.Fatalf("status lost: %+v", got.DataSeries[5])
+	}
+	if _, err := s.Query("nope", t0, t0.Add(time.Hour)); err != timeseries.ErrUnknownSeries {
+		t.Fatalf("expected ErrUnknownSeries, got %v", err)
+	}
+}
+
+func TestStore_RecoveryFromWAL(t *testing.T) {
+	dir := t.TempDir()
+	s, err := Open(dir, Options{})
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	if err := s.Append("a", hourly(3, 0)...); err != nil {
+		t.Fatalf("append: %v", err)
+	}
+	// Simulate a crash: no Flush or Close, and a torn record at the end.
+	s.wal.Close()
+	f, _ := os.OpenFile(filepath.Join(dir, walName), os.O_APPEND|os.O_WRONLY, 0)
+	f.Write(appendWALRecord(nil, "a", hourly(4, 10)[3])[:7])
+	f.Close()
+	os.WriteFile(filepath.Join(dir, "leftover"+segExt+tmpExt), []byte("partial"), 0o644)
+
+	s, err = Open(dir, Options{})
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	defer s.Close()
+	got, err := s.Query("a", t0, t0.Add(24*time.Hour))
+	if err != nil || len(got.DataSeries) != 3 || got.DataSeries[2].Meas != 2 {
+		t.Fatalf("recovered %+v, %v", got.DataSeries, err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "leftover"+segExt+tmpExt)); !os.IsNotExist(err) {
+		t.Fatal("temporary files must be removed on open")
+	}
+	// The torn tail is cut, so new records follow the valid ones.
+	if err := s.Append("a", timeseries.NewDataUnit(t0.Add(3*time.Hour), 3)); err != nil {
+		t.Fatalf("append: %v", err)
+	}
+	s.wal.Close()
+	s, err = Open(dir, Options{})
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	if got, _ := s.Query("a", t0, t0.Add(24*time.Hour)); len(got.DataSeries) != 4 {
+		t.Fatalf("expected 4 points after second recovery, got %d", len(got.DataSeries))
+	}
+}
+
+func TestStore_OverwriteAndCompact(t *testing.T) {
+	dir := t.TempDir()
+	s, err := Open(dir, Options{Partition: 12 * time.Hour, BlockSize: 8})
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	tsc := timeseries.NewTsContainer()
+	a := timeseries.TimeSeries{Name: "a", DataSeries: hourly(24, 0)}
+	b := timeseries.TimeSeries{Name: "b", DataSeries: hourly(6, 100)}
+	tsc.Ts["a"], tsc.Ts["b"] = &a, &b
+	if err := s.Write(&tsc); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	s.Flush()
+	// A correction of one point, flushed to a second segment.
+	fix := timeseries.NewDataUnitWithStatus(t0.Add(3*time.Hour), 33, timeseries.StImputed)
+	s.Append("a", fix)
+	s.Flush()
+	if n := segmentCount(t, dir); n != 4 {
+		t.Fatalf("expected 4 segments before compaction, got %d", n)
+	}
+
+	before, _ := s.Query("a", t0, t0.Add(24*time.Hour))
+	if before.DataSeries[3].Meas != 33 || before.DataSeries[3].Status != timeseries.StImputed || len(before.DataSeries) != 24 {
+		t.Fatalf("latest write must win: %+v", before.DataSeries[3])
+	}
+	if err := s.Compact(); err != nil {
+		t.Fatalf("compact: %v", err)
+	}
+	if n := segmentCount(t, dir); n != 3 {
+		t.Fatalf("expected 3 segments after compaction, got %d", n)
+	}
+	s.Close()
+
+	s, err = Open(dir, Options{Partition: 12 * time.Hour})
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	defer s.Close()
+	after, _ := s.Query("a", t0, t0.Add(24*time.Hour))
+	for i, d := range after.DataSeries {
+		if d.Meas != before.DataSeries[i].Meas || d.Status != before.DataSeries[i].Status {
+			t.Fatalf("point %d changed by compaction: %+v", i, d)
+		}
+	}
+	if names := s.Names(); len(names) != 2 || names[0] != "a" || names[1] != "b" {
+		t.Fatalf("unexpected names %v", names)
+	}
+}
+
+func TestStore_Closed(t *testing.T) {
+	s, err := Open(t.TempDir(), Options{})
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	s.Close()
+	if err := s.Append("a"); err != ErrClosed {
+		t.Fatalf("expected ErrClosed, got %v", err)
+	}
+	if _, err := Open(t.TempDir(), Options{Partition: -time.Hour}); err != timeseries.ErrBounds {
+		t.Fatalf("expected ErrBounds, got %v", err)
+	}
+}
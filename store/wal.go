@@ -0,0 +1,107 @@
+package store
+
+import (
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+	"math"
+	"os"
+	"time"
+
+	"github.com/usefulrisk/timeseries"
+)
+
+// walName is the file name of the write-ahead log in the store directory.
+const walName = "wal.log"
+
+// WAL record layout (varints as in encoding/binary):
+//
+//	uvarint len(payload) | payload | crc32 (IEEE, little endian) of payload
+//	payload: uvarint len(name) | name | varint unix ns | uint64 value bits | status byte
+//
+// A crash can leave a partial record at the end of the log; replay stops
+// at the first record that is truncated or fails its checksum.
+
+// appendWALRecord appends the record of one point to b.
+func appendWALRecord(b []byte, name string, d timeseries.DataUnit) []byte {
+	var p []byte
+	p = binary.AppendUvarint(p, uint64(len(name)))
+	p = append(p, name...)
+	p = binary.AppendVarint(p, d.Chron.UnixNano())
+	p = binary.LittleEndian.AppendUint64(p, math.Float64bits(d.Meas))
+	p = append(p, byte(d.Status))
+
+	b = binary.AppendUvarint(b, uint64(len(p)))
+	b = append(b, p...)
+	return binary.LittleEndian.AppendUint32(b, crc32.ChecksumIEEE(p))
+}
+
+// replayWAL reads the log at path and calls fn on every valid record. It
+// returns the length of the valid prefix of the log, so that a torn tail
+// can be truncated away.
+func replayWAL(path string, fn func(name string, d timeseries.DataUnit)) (int64, error) {
+	b, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	pos := 0
+	for pos < len(b) {
+		n, k := binary.Uvarint(b[pos:])
+		if k <= 0 || n > uint64(len(b)-pos-k) || uint64(len(b)-pos-k)-n < 4 {
+			break
+		}
+		p := b[pos+k : pos+k+int(n)]
+		sum := binary.LittleEndian.Uint32(b[pos+k+int(n):])
+		if crc32.ChecksumIEEE(p) != sum {
+			break
+		}
+		name, d, ok := decodeWALPayload(p)
+		if !ok {
+			break
+		}
+		fn(name, d)
+		pos += k + int(n) + 4
+	}
+	return int64(pos), nil
+}
+
+// decodeWALPayload decodes the payload of one record.
+func decodeWALPayload(p []byte) (string, timeseries.DataUnit, bool) {
+	nameLen, k := binary.Uvarint(p)
+	if k <= 0 || nameLen > uint64(len(p)-k) {
+		return "", timeseries.DataUnit{}, false
+	}
+	name := string(p[k : k+int(nameLen)])
+	p = p[k+int(nameLen):]
+	ns, k := binary.Varint(p)
+	if k <= 0 || len(p)-k != 9 {
+		return "", timeseries.DataUnit{}, false
+	}
+	p = p[k:]
+	d := timeseries.DataUnit{
+		Chron:  time.Unix(0, ns).UTC(),
+		Meas:   math.Float64frombits(binary.LittleEndian.Uint64(p)),
+		Status: timeseries.StatusCode(p[8]),
+	}
+	return name, d, true
+}
+
+// openWAL opens the log for appending after cutting it to size bytes.
+func openWAL(path string, size int64) (*os.File, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	if err := f.Truncate(size); err != nil {
+		f.Close()
+		return nil, err
+	}
+	if _, err := f.Seek(size, io.SeekStart); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return f, nil
+}
@@ -14,9 +14,11 @@ import (
 //   - StOutlier:  the observation was flagged as an outlier by a detector.
 //   - StInvalid:  the observation exists but must not be used (bad sensor,
 //     parse error, unit mismatch, etc.).
+//   - StImputed:  the value was not observed but filled in by a gap-filling
+//     policy (see FillGaps); stats may include or exclude it explicitly.
 type StatusCode uint8
 
-// StOK, StMissing, StOutlier and StInvalid enumerate the canonical states
+// StOK, StMissing, StOutlier, StInvalid and StImputed enumerate the canonical states
 // an observation can be in. Consumers should prefer StatusCode over ad-hoc
 // sentinels (like NaN-only) because it’s explicit and type-safe.
 const (
@@ -24,8 +26,25 @@ const (
 	StMissing                   // missing value (gap)
 	StOutlier                   // flagged outlier
 	StInvalid                   // present but unusable
+	StImputed                   // filled in, not observed
 )
 
+// statusSeverity ranks status codes from the most to the least trustworthy
+// when several observations contribute to one derived value.
+var statusSeverity = [...]int{StOK: 0, StImputed: 1, StOutlier: 2, StMissing: 3, StInvalid: 4}
+
+// worstStatus returns the least trustworthy of the given status codes
+// (StOK < StImputed < StOutlier < StMissing < StInvalid).
+func worstStatus(sts ...StatusCode) StatusCode {
+	worst := StOK
+	for _, st := range sts {
+		if int(st) < len(statusSeverity) && statusSeverity[st] > statusSeverity[worst] {
+			worst = st
+		}
+	}
+	return worst
+}
+
 // DataUnit represents a single timestamped measurement and its meta-state.
 //
 // Fields (typical usage):
@@ -54,10 +73,15 @@ type DataUnit struct {
 //
 // TimeSeries interoperates well with JSON/CSV/DB sources and can be converted
 // to DTOs (see TimeSeriesJSON) for transport or storage.
+//
+// Annotations optionally records, per point, why it has its status (see
+// Annotate). It is keyed by the Unix nanoseconds of the point's Chron so
+// that it survives sorting and merging; nil means no annotation.
 type TimeSeries struct {
-	Name       string
-	Comment    string
-	DataSeries []DataUnit
+	Name        string
+	Comment     string
+	DataSeries  []DataUnit
+	Annotations map[int64][]Annotation
 	BasicStats
 }
 
@@ -122,6 +146,7 @@ type TsContainer struct {
 //   - Dmeas:   []*float64 (nil for missing)
 //   - Status:  []StatusCode
 //   - Stats:   *BasicStatsJSON
+//   - Annotations: map[RFC3339 timestamp][]Annotation
 //
 // The DTO keeps missing-data semantics explicit for safer cross-language usage.
 type TimeSeriesJSON struct {
@@ -133,10 +158,15 @@ type TimeSeriesJSON struct {
 	Dmeas    []*float64      `json:"dmeas,omitempty"`
 	Status   []StatusCode    `json:"status,omitempty"`
 	Stats    *BasicStatsJSON `json:"stats,omitempty"`
+	// Annotations maps RFC 3339 timestamps (nanosecond precision) to the
+	// annotations of the point at that time.
+	Annotations map[string][]Annotation `json:"annotations,omitempty"`
 }
 
 // BasicStatsJSON is the serialized counterpart to BasicStats. It mirrors the
 // same semantics but uses JSON-friendly field types (time.Time, float64, int).
+// Non-finite values, such as the stats of a series without valid points,
+// are encoded as null and decoded back as NaN.
 type BasicStatsJSON struct {
 	Len        int       `json:"len"`
 	Chmin      time.Time `json:"chmin"`
@@ -207,6 +237,15 @@ func NewDataUnitWithStatus(chr time.Time, meas float64, status StatusCode) DataU
 //   - AggMean: use the arithmetic mean of the bucket.
 //   - AggLast: take the last (rightmost) sample in the bucket.
 //   - AggSum:  sum all samples in the bucket (useful for counters/energy).
+//   - AggTimeWeighted: time-weighted mean where each sample holds its value
+//     until the next one (step-hold, for report-on-change sensors).
+//   - AggTrapezoid: time-weighted mean of the piecewise-linear signal
+//     joining consecutive samples (trapezoidal rule).
+//
+// The time-weighted modes integrate over the whole bucket, including the
+// part carried in from the last sample of the previous bucket, so a bucket
+// with no sample of its own still gets a value when it lies between two
+// samples.
 type Agg int
 
 // Aggregation modes for resampling/regularization. Choose the one that
@@ -218,4 +257,256 @@ const (
 	AggMean
 	AggLast
 	AggSum
+	AggTimeWeighted
+	AggTrapezoid
+)
+
+// LeftOrRight selects which edge of a regular bucket is used as the
+// timestamp of the condensed observation.
+//
+// Semantics:
+//   - LabelLeft:  stamp the bucket with its start (inclusive) boundary.
+//   - LabelRight: stamp the bucket with its end (exclusive) boundary,
+//     which is the usual convention for meter readings.
+type LeftOrRight int
+
+const (
+	LabelLeft LeftOrRight = iota
+	LabelRight
+)
+
+// FillPolicy enumerates how missing points are filled, e.g. buckets without
+// any sample after regularization (see RegularizeWith and FillGaps).
+//
+// Semantics:
+//   - FillNone:     leave the bucket as a gap (Meas=NaN, Status=StMissing).
+//   - FillPrevious: carry the last valid value forward (LOCF).
+//   - FillNext:     carry the next valid value backward (NOCB).
+//   - FillLinear:   interpolate linearly in time between both neighbors.
+//   - FillNearest:  take the value of the closest neighbor in time.
+//   - FillConstant: use a caller-supplied constant.
+//
+// Filled points are tagged StImputed.
+type FillPolicy int
+
+const (
+	FillNone FillPolicy = iota
+	FillPrevious
+	FillNext
+	FillLinear
+	FillNearest
+	FillConstant
+)
+
+// CalendarPeriod enumerates calendar-aware bucket widths. Unlike a fixed
+// time.Duration, a calendar bucket follows the civil calendar of a
+// time.Location, so a local day spans 23 or 25 hours on DST transitions and
+// months have their actual length.
+//
+// Semantics:
+//   - CalNone:    no calendar bucketing (use a fixed Period).
+//   - CalDay:     local midnight to local midnight.
+//   - CalWeek:    ISO week, starting Monday at local midnight.
+//   - CalMonth:   first day of the month to first day of the next one.
+//   - CalQuarter: calendar quarters starting January, April, July, October.
+//   - CalYear:    January 1st to January 1st.
+type CalendarPeriod int
+
+const (
+	CalNone CalendarPeriod = iota
+	CalDay
+	CalWeek
+	CalMonth
+	CalQuarter
+	CalYear
+)
+
+// Interp enumerates the interpolation methods used to evaluate a series at
+// timestamps it does not have (see Resample).
+//
+// Semantics:
+//   - InterpStep:    previous value (step-hold / zero-order hold).
+//   - InterpLinear:  straight line between the two neighbors.
+//   - InterpNearest: value of the closest neighbor in time.
+//   - InterpCubic:   natural cubic spline (smooth, may overshoot).
+//   - InterpPCHIP:   piecewise cubic Hermite, shape-preserving (monotone
+//     data stays monotone, no overshoot).
+//   - InterpAkima:   Akima spline, robust to isolated wiggles.
+//   - InterpExact:   no interpolation: only exact timestamps get a value,
+//     other points are left missing.
+//
+// The cubic methods fall back to linear interpolation with two points.
+type Interp int
+
+const (
+	InterpStep Interp = iota
+	InterpLinear
+	InterpNearest
+	InterpCubic
+	InterpPCHIP
+	InterpAkima
+	InterpExact
+)
+
+// Extrapolation enumerates what happens outside the time span covered by
+// the observations.
+//
+// Semantics:
+//   - ExtrapNone:   no value (Meas=NaN, Status=StMissing).
+//   - ExtrapHold:   repeat the first/last observation.
+//   - ExtrapLinear: extend the line through the two outermost observations.
+//
+// Extrapolated values are tagged StImputed.
+type Extrapolation int
+
+const (
+	ExtrapNone Extrapolation = iota
+	ExtrapHold
+	ExtrapLinear
+)
+
+// AlignMode enumerates how the common timeline of several series is built
+// (see TsContainer.Align).
+//
+// Semantics:
+//   - AlignUnion:        every timestamp present in at least one series.
+//   - AlignIntersection: only timestamps present in every series.
+//   - AlignGrid:         a regular grid anchor + k*period covering all series.
+type AlignMode int
+
+const (
+	AlignUnion AlignMode = iota
+	AlignIntersection
+	AlignGrid
+)
+
+// AsOfDirection selects which right-hand observation an as-of join attaches
+// to a left-hand timestamp (see AsOf).
+//
+// Semantics:
+//   - AsOfBackward: the latest observation at or before the timestamp.
+//   - AsOfForward:  the earliest observation at or after the timestamp.
+//   - AsOfNearest:  the closest observation, ties going backward.
+type AsOfDirection int
+
+const (
+	AsOfBackward AsOfDirection = iota
+	AsOfForward
+	AsOfNearest
+)
+
+// JoinKind enumerates how the timestamps of two series are matched before
+// an elementwise operation (see Combine).
+//
+// Semantics:
+//   - JoinExact:   only timestamps present in both series.
+//   - JoinAsOf:    every timestamp of the left series, matched with the
+//     latest right observation at or before it (within a tolerance).
+//   - JoinRegular: both series are first regularized on the same grid, then
+//     matched exactly bucket by bucket.
+type JoinKind int
+
+const (
+	JoinExact JoinKind = iota
+	JoinAsOf
+	JoinRegular
+)
+
+// JoinMode parameterizes a join: Tolerance is the maximum look-back of
+// JoinAsOf, with the convention of AsOf (0 accepts exact matches only,
+// NoTolerance means no limit), and Regular the grid used by JoinRegular.
+type JoinMode struct {
+	Kind      JoinKind
+	Tolerance time.Duration
+	Regular   RegularizeOpts
+}
+
+// NoTolerance, as the tolerance of AsOf or JoinMode, lifts any limit on
+// the distance between matched timestamps. Any negative tolerance has the
+// same effect.
+const NoTolerance time.Duration = -1
+
+// RollStat enumerates the statistics computed over a rolling window (see
+// Rolling and RollingN).
+//
+// Semantics:
+//   - RollMean:     arithmetic mean.
+//   - RollSum:      sum.
+//   - RollMin:      minimum.
+//   - RollMax:      maximum.
+//   - RollStd:      sample standard deviation (denominator n-1).
+//   - RollMedian:   median.
+//   - RollQuantile: quantile RollingOpts.Q, linearly interpolated between
+//     order statistics.
+//   - RollCount:    number of valid observations.
+//   - RollCustom:   RollingOpts.Reducer applied to the window values.
+type RollStat int
+
+const (
+	RollMean RollStat = iota
+	RollSum
+	RollMin
+	RollMax
+	RollStd
+	RollMedian
+	RollQuantile
+	RollCount
+	RollCustom
+)
+
+// Tail selects which side of the distribution a statistical outlier test
+// examines (see Grubbs).
+//
+// Semantics:
+//   - TailBoth:  the observation farthest from the mean, on either side.
+//   - TailUpper: the largest observation only.
+//   - TailLower: the smallest observation only.
+type Tail int
+
+const (
+	TailBoth Tail = iota
+	TailUpper
+	TailLower
+)
+
+// QualityReason identifies the data-quality rule that rejected a point
+// (see QualityRules). Reasons are strings so that reports stay readable
+// once exported.
+//
+// Semantics:
+//   - ReasonRange:      value outside the physical range (StInvalid).
+//   - ReasonRate:       change faster than the maximum rate (StOutlier).
+//   - ReasonFlatline:   value stuck for too long (StInvalid).
+//   - ReasonSpike:      isolated spike that returns to the previous level
+//     (StOutlier).
+//   - ReasonDuplicate:  same timestamp as the previous point (StInvalid).
+//   - ReasonRegression: timestamp earlier than a previous one (StInvalid).
+type QualityReason string
+
+const (
+	ReasonRange      QualityReason = "range"
+	ReasonRate       QualityReason = "rate_of_change"
+	ReasonFlatline   QualityReason = "flatline"
+	ReasonSpike      QualityReason = "spike"
+	ReasonDuplicate  QualityReason = "duplicate_timestamp"
+	ReasonRegression QualityReason = "timestamp_regression"
+)
+
+// TimeEncoding enumerates how timestamps are written in text formats such
+// as CSV (see CSVOpts).
+//
+// Semantics:
+//   - TimeRFC3339:   RFC 3339 with optional fractional seconds.
+//   - TimeUnix:      seconds since the Unix epoch, possibly fractional.
+//   - TimeUnixMilli: milliseconds since the Unix epoch.
+//   - TimeUnixNano:  nanoseconds since the Unix epoch.
+//   - TimeLayout:    a time.Parse layout (see CSVOpts.Layouts).
+type TimeEncoding int
+
+const (
+	TimeRFC3339 TimeEncoding = iota
+	TimeUnix
+	TimeUnixMilli
+	TimeUnixNano
+	TimeLayout
 )